@@ -8,12 +8,15 @@ import (
 	"encoding/hex"
 	"net/http"
 	"net/url"
+
+	"github.com/wneverfade/wechatpay-b2b/model"
 )
 
 // Client 封装访问微信 API 的 HTTP 客户端。
 type Client struct {
 	baseURL     string
 	accessToken string // access_token
+	env         model.Env
 	httpClient  *http.Client
 }
 
@@ -22,6 +25,11 @@ func (c *Client) GetAccessToken() string {
 	return c.accessToken
 }
 
+// GetEnv 获取支付环境。
+func (c *Client) GetEnv() model.Env {
+	return c.env
+}
+
 // Do 向指定 uri（路径，不含完整域名）发起 HTTP 请求。
 func (c *Client) Do(ctx context.Context, method, uri string, body []byte) (*http.Response, error) {
 	httpClient := c.httpClient
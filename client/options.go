@@ -2,12 +2,16 @@ package client
 
 import (
 	"errors"
+
+	"github.com/wneverfade/wechatpay-b2b/model"
 )
 
 // Options Client 初始化参数。
 type Options struct {
 	// AccessToken 接口调用凭证（可由业务侧定时刷新并更新到 Client 上）。
 	AccessToken string
+	// Env 支付环境，默认 model.EnvProd，用于校验下单参数中的 env。
+	Env model.Env
 }
 
 // NewClient 创建一个可复用的微信 API Client。
@@ -16,8 +20,12 @@ func NewClient(opts Options) (*Client, error) {
 	if opts.AccessToken == "" {
 		return nil, errors.New("accessToken is empty")
 	}
+	if opts.Env != model.EnvProd && opts.Env != model.EnvSandbox {
+		return nil, errors.New("env is invalid")
+	}
 	c := &Client{}
 	c.accessToken = opts.AccessToken
+	c.env = opts.Env
 	c.baseURL = "https://api.weixin.qq.com"
 
 	return c, nil
@@ -13,7 +13,7 @@ const (
 // BalanceInfo 表示账户余额条目。
 type BalanceInfo struct {
 	BalanceType BalanceType `json:"balance_type"`
-	Amount      string      `json:"amount"`
+	Amount      YuanString  `json:"amount"` // 以元为单位的字符串
 	Currency    string      `json:"currency"`
 }
 
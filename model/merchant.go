@@ -0,0 +1,194 @@
+package model
+
+import "strconv"
+
+// ApplymentState 表示商户进件申请单状态。
+type ApplymentState string
+
+const (
+	// ApplymentChecking 资料校验中
+	ApplymentChecking ApplymentState = "CHECKING"
+	// ApplymentAccountNeedVerify 待账户验证，需按 account_validation 汇款验证
+	ApplymentAccountNeedVerify ApplymentState = "ACCOUNT_NEED_VERIFY"
+	// ApplymentAuditing 审核中
+	ApplymentAuditing ApplymentState = "AUDITING"
+	// ApplymentRejected 已驳回，驳回原因见 audit_detail
+	ApplymentRejected ApplymentState = "REJECTED"
+	// ApplymentNeedSign 待签约，需打开 sign_url 签约
+	ApplymentNeedSign ApplymentState = "NEED_SIGN"
+	// ApplymentFinish 完成，已生成 sub_mchid
+	ApplymentFinish ApplymentState = "FINISH"
+	// ApplymentFrozen 已冻结
+	ApplymentFrozen ApplymentState = "FROZEN"
+	// ApplymentCanceled 已作废
+	ApplymentCanceled ApplymentState = "CANCELED"
+)
+
+// IsKnown 判断是否为已定义的申请单状态。
+func (s ApplymentState) IsKnown() bool {
+	switch s {
+	case ApplymentChecking, ApplymentAccountNeedVerify, ApplymentAuditing, ApplymentRejected,
+		ApplymentNeedSign, ApplymentFinish, ApplymentFrozen, ApplymentCanceled:
+		return true
+	}
+	return false
+}
+
+// IsTerminal 判断申请单是否已结束（完成或作废），驳回的申请单可修改后重新提交，不视为终态。
+func (s ApplymentState) IsTerminal() bool {
+	return s == ApplymentFinish || s == ApplymentCanceled
+}
+
+// SignState 表示商户进件签约状态。
+type SignState string
+
+const (
+	// SignStateUnsigned 未签约
+	SignStateUnsigned SignState = "UNSIGNED"
+	// SignStateSigned 已签约
+	SignStateSigned SignState = "SIGNED"
+	// SignStateNotSignable 暂不可签约
+	SignStateNotSignable SignState = "NOT_SIGNABLE"
+)
+
+// IsKnown 判断是否为已定义的签约状态。
+func (s SignState) IsKnown() bool {
+	return s == SignStateUnsigned || s == SignStateSigned || s == SignStateNotSignable
+}
+
+// OrganizationType 表示进件主体类型。
+type OrganizationType uint32
+
+const (
+	// OrganizationEnterprise 企业
+	OrganizationEnterprise OrganizationType = 2
+	// OrganizationInstitution 党政、机关及事业单位
+	OrganizationInstitution OrganizationType = 3
+	// OrganizationIndividualBusiness 个体工商户
+	OrganizationIndividualBusiness OrganizationType = 4
+	// OrganizationOther 其他组织
+	OrganizationOther OrganizationType = 1708
+	// OrganizationMicro 小微商户
+	OrganizationMicro OrganizationType = 2401
+	// OrganizationPersonalSeller 个人卖家
+	OrganizationPersonalSeller OrganizationType = 2500
+)
+
+// IsValid 判断是否为已定义的主体类型。
+func (t OrganizationType) IsValid() bool {
+	switch t {
+	case OrganizationEnterprise, OrganizationInstitution, OrganizationIndividualBusiness,
+		OrganizationOther, OrganizationMicro, OrganizationPersonalSeller:
+		return true
+	}
+	return false
+}
+
+// NeedsBusinessLicense 判断主体类型是否需要营业执照（小微商户与个人卖家无需）。
+func (t OrganizationType) NeedsBusinessLicense() bool {
+	return t != OrganizationMicro && t != OrganizationPersonalSeller
+}
+
+// String 返回主体类型名称。
+func (t OrganizationType) String() string {
+	switch t {
+	case OrganizationEnterprise:
+		return "ENTERPRISE"
+	case OrganizationInstitution:
+		return "INSTITUTION"
+	case OrganizationIndividualBusiness:
+		return "INDIVIDUAL_BUSINESS"
+	case OrganizationOther:
+		return "OTHER"
+	case OrganizationMicro:
+		return "MICRO"
+	case OrganizationPersonalSeller:
+		return "PERSONAL_SELLER"
+	}
+	return "OrganizationType(" + strconv.FormatUint(uint64(t), 10) + ")"
+}
+
+// OpenType 表示进件开通的收款方式。
+type OpenType uint32
+
+const (
+	// OpenTypeWxPay 开通微信支付
+	OpenTypeWxPay OpenType = 1
+	// OpenTypeBankTransfer 开通银行转账
+	OpenTypeBankTransfer OpenType = 2
+	// OpenTypeAll 同时开通微信支付与银行转账
+	OpenTypeAll OpenType = 3
+)
+
+// IsValid 判断是否为已定义的开通方式。
+func (t OpenType) IsValid() bool {
+	return t >= OpenTypeWxPay && t <= OpenTypeAll
+}
+
+// String 返回开通方式名称。
+func (t OpenType) String() string {
+	switch t {
+	case OpenTypeWxPay:
+		return "WXPAY"
+	case OpenTypeBankTransfer:
+		return "BANK_TRANSFER"
+	case OpenTypeAll:
+		return "ALL"
+	}
+	return "OpenType(" + strconv.FormatUint(uint64(t), 10) + ")"
+}
+
+// IDDocType 表示经营者/法人证件类型，对应 id_doc_type_num。
+type IDDocType uint32
+
+const (
+	// IDDocMainlandIDCard 中国大陆居民身份证
+	IDDocMainlandIDCard IDDocType = 1
+	// IDDocOverseaPassport 其他国家或地区居民护照
+	IDDocOverseaPassport IDDocType = 2
+	// IDDocHongKongPassport 中国香港居民来往内地通行证
+	IDDocHongKongPassport IDDocType = 3
+	// IDDocMacaoPassport 中国澳门居民来往内地通行证
+	IDDocMacaoPassport IDDocType = 4
+	// IDDocTaiwanPassport 中国台湾居民来往大陆通行证
+	IDDocTaiwanPassport IDDocType = 5
+	// IDDocForeignResident 外国人居留证
+	IDDocForeignResident IDDocType = 6
+	// IDDocHongKongMacaoResident 港澳居民居住证
+	IDDocHongKongMacaoResident IDDocType = 7
+	// IDDocTaiwanResident 台湾居民居住证
+	IDDocTaiwanResident IDDocType = 8
+)
+
+// IsValid 判断是否为已定义的证件类型。
+func (t IDDocType) IsValid() bool {
+	return t >= IDDocMainlandIDCard && t <= IDDocTaiwanResident
+}
+
+// IsIDCard 判断是否为中国大陆居民身份证，身份证填写 id_card_info，其它证件填写 id_doc_info。
+func (t IDDocType) IsIDCard() bool {
+	return t == IDDocMainlandIDCard
+}
+
+// String 返回证件类型名称。
+func (t IDDocType) String() string {
+	switch t {
+	case IDDocMainlandIDCard:
+		return "MAINLAND_IDCARD"
+	case IDDocOverseaPassport:
+		return "OVERSEA_PASSPORT"
+	case IDDocHongKongPassport:
+		return "HONGKONG_PASSPORT"
+	case IDDocMacaoPassport:
+		return "MACAO_PASSPORT"
+	case IDDocTaiwanPassport:
+		return "TAIWAN_PASSPORT"
+	case IDDocForeignResident:
+		return "FOREIGN_RESIDENT"
+	case IDDocHongKongMacaoResident:
+		return "HONGKONG_MACAO_RESIDENT"
+	case IDDocTaiwanResident:
+		return "TAIWAN_RESIDENT"
+	}
+	return "IDDocType(" + strconv.FormatUint(uint64(t), 10) + ")"
+}
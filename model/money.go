@@ -0,0 +1,178 @@
+package model
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// ErrMoneyOverflow 表示金额运算溢出。
+var ErrMoneyOverflow = errors.New("money overflow")
+
+// Money 表示以分为单位的金额。
+// JSON 序列化为整数（分），反序列化兼容整数与整数字符串（均按分解析）。以元为单位的字符串字段使用 YuanString。
+type Money int64
+
+// Fen 返回以分为单位的整数值。
+func (m Money) Fen() int64 {
+	return int64(m)
+}
+
+// Add 返回 m + o，溢出时返回 ErrMoneyOverflow。
+func (m Money) Add(o Money) (Money, error) {
+	if (o > 0 && m > math.MaxInt64-o) || (o < 0 && m < math.MinInt64-o) {
+		return 0, ErrMoneyOverflow
+	}
+	return m + o, nil
+}
+
+// Sub 返回 m - o，溢出时返回 ErrMoneyOverflow。
+func (m Money) Sub(o Money) (Money, error) {
+	if (o < 0 && m > math.MaxInt64+o) || (o > 0 && m < math.MinInt64+o) {
+		return 0, ErrMoneyOverflow
+	}
+	return m - o, nil
+}
+
+// Mul 返回 m * n，溢出时返回 ErrMoneyOverflow。
+func (m Money) Mul(n int64) (Money, error) {
+	if m == 0 || n == 0 {
+		return 0, nil
+	}
+	r := int64(m) * n
+	if r/n != int64(m) || (m == -1 && n == math.MinInt64) || (n == -1 && m == math.MinInt64) {
+		return 0, ErrMoneyOverflow
+	}
+	return Money(r), nil
+}
+
+// SumMoney 返回多个金额之和，溢出时返回 ErrMoneyOverflow。
+func SumMoney(values ...Money) (Money, error) {
+	var total Money
+	for _, v := range values {
+		var err error
+		if total, err = total.Add(v); err != nil {
+			return 0, err
+		}
+	}
+	return total, nil
+}
+
+// Yuan 返回以元为单位、保留两位小数的字符串，如 "12.34"。
+func (m Money) Yuan() string {
+	v := int64(m)
+	sign := ""
+	if v < 0 {
+		sign = "-"
+	}
+	// 取绝对值时使用 uint64 避免 MinInt64 溢出。
+	u := uint64(v)
+	if v < 0 {
+		u = uint64(-(v + 1)) + 1
+	}
+	return fmt.Sprintf("%s%d.%02d", sign, u/100, u%100)
+}
+
+// ParseYuan 将以元为单位的字符串解析为金额，最多两位小数，如 "12"、"12.3"、"-0.01"。
+func ParseYuan(s string) (Money, error) {
+	str := strings.TrimSpace(s)
+	neg := false
+	if strings.HasPrefix(str, "-") {
+		neg = true
+		str = str[1:]
+	} else if strings.HasPrefix(str, "+") {
+		str = str[1:]
+	}
+	intPart, fracPart, hasDot := strings.Cut(str, ".")
+	if intPart == "" || (hasDot && fracPart == "") || len(fracPart) > 2 {
+		return 0, fmt.Errorf("invalid yuan amount %q", s)
+	}
+	for _, c := range intPart + fracPart {
+		if c < '0' || c > '9' {
+			return 0, fmt.Errorf("invalid yuan amount %q", s)
+		}
+	}
+	for len(fracPart) < 2 {
+		fracPart += "0"
+	}
+	fen, err := strconv.ParseInt(intPart+fracPart, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid yuan amount %q: %w", s, ErrMoneyOverflow)
+	}
+	if neg {
+		fen = -fen
+	}
+	return Money(fen), nil
+}
+
+// MarshalJSON 序列化为整数（分）。
+func (m Money) MarshalJSON() ([]byte, error) {
+	return strconv.AppendInt(nil, int64(m), 10), nil
+}
+
+// UnmarshalJSON 兼容整数（分）与整数字符串（分），不接受小数。
+func (m *Money) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if bytes.Equal(data, []byte("null")) {
+		return nil
+	}
+	if len(data) > 0 && data[0] == '"' {
+		s, err := strconv.Unquote(string(data))
+		if err != nil {
+			return err
+		}
+		if s == "" {
+			*m = 0
+			return nil
+		}
+		data = []byte(s)
+	}
+	v, err := strconv.ParseInt(string(data), 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid money %s: %w", data, err)
+	}
+	*m = Money(v)
+	return nil
+}
+
+// YuanString 表示线上以元为单位的字符串金额（如余额接口的 amount："12.34"），内部按分保存。
+// JSON 序列化为元字符串，反序列化兼容元字符串与数字（均按元解析）。
+type YuanString Money
+
+// Money 返回以分为单位的金额。
+func (y YuanString) Money() Money {
+	return Money(y)
+}
+
+// MarshalJSON 序列化为元字符串，如 "12.34"。
+func (y YuanString) MarshalJSON() ([]byte, error) {
+	return strconv.AppendQuote(nil, Money(y).Yuan()), nil
+}
+
+// UnmarshalJSON 按元解析字符串或数字。
+func (y *YuanString) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if bytes.Equal(data, []byte("null")) {
+		return nil
+	}
+	s := string(data)
+	if len(data) > 0 && data[0] == '"' {
+		var err error
+		if s, err = strconv.Unquote(s); err != nil {
+			return err
+		}
+		if s == "" {
+			*y = 0
+			return nil
+		}
+	}
+	v, err := ParseYuan(s)
+	if err != nil {
+		return err
+	}
+	*y = YuanString(v)
+	return nil
+}
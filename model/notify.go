@@ -1,18 +1,41 @@
 package model
 
+import (
+	"encoding/json"
+	"errors"
+)
+
+// NotifyEvent 表示消息推送的事件类型。
+type NotifyEvent string
+
+const (
+	// EventPayment 支付成功通知
+	EventPayment NotifyEvent = "retail_pay_notify"
+	// EventRefund 退款结果通知
+	EventRefund NotifyEvent = "retail_refund_notify"
+	// EventWithdraw 提现结果通知
+	EventWithdraw NotifyEvent = "retail_withdraw_notify"
+	// EventProfitSharing 分账结果通知
+	EventProfitSharing NotifyEvent = "retail_profit_sharing_notify"
+	// EventProfitSharingReturn 分账回退结果通知
+	EventProfitSharingReturn NotifyEvent = "retail_profit_sharing_return_notify"
+	// EventMerchantRegister 商户进件状态变更通知
+	EventMerchantRegister NotifyEvent = "retail_mch_register_notify"
+)
+
 // PaymentNotify 表示支付成功通知的 JSON 结构体。
 type PaymentNotify struct {
 	ToUserName         string        `json:"ToUserName"`           // 公众号/小程序ID
 	FromUserName       string        `json:"FromUserName"`         // 用户OpenID
-	CreateTime         int64         `json:"CreateTime"`           // 创建时间
+	CreateTime         UnixTime      `json:"CreateTime"`           // 创建时间
 	MsgType            string        `json:"MsgType"`              // 消息类型 固定：event
 	Event              string        `json:"Event"`                // 事件类型 固定：retail_pay_notify
 	AppID              string        `json:"appid"`                // 小程序ID
 	Mchid              string        `json:"mchid"`                // 微信商户号
 	OutTradeNo         string        `json:"out_trade_no"`         // 商户订单号
 	OrderID            string        `json:"order_id"`             // B2b支付订单号
-	PayStatus          string        `json:"pay_status"`           // 支付状态
-	PayTime            string        `json:"pay_time"`             // 支付完成时间
+	PayStatus          PayStatus     `json:"pay_status"`           // 支付状态
+	PayTime            Time          `json:"pay_time"`             // 支付完成时间
 	Attach             string        `json:"attach"`               // 附加数据
 	PayerOpenID        string        `json:"payer_openid"`         // 支付者OpenID
 	Amount             PaymentAmount `json:"amount"`               // 订单金额信息
@@ -22,29 +45,166 @@ type PaymentNotify struct {
 
 // PaymentAmount 表示通知中的金额信息。
 type PaymentAmount struct {
-	OrderAmount int64  `json:"order_amount"` // 订单金额
-	PayerAmount int64  `json:"payer_amount"` // 支付者实付金额
+	OrderAmount Money  `json:"order_amount"` // 订单金额
+	PayerAmount Money  `json:"payer_amount"` // 支付者实付金额
 	Currency    string `json:"currency"`     // 货币类型
 }
 
 // RefundNotify 表示退款通知的 JSON 结构体。
 type RefundNotify struct {
-	ToUserName   string `json:"ToUserName"`    // 公众号/小程序ID
-	FromUserName string `json:"FromUserName"`  // 用户OpenID
-	CreateTime   int64  `json:"CreateTime"`    // 创建时间
-	MsgType      string `json:"MsgType"`       // 消息类型
-	Event        string `json:"Event"`         // 事件类型 retail_refund_notify
-	AppID        string `json:"appid"`         // 小程序ID
-	Mchid        string `json:"mchid"`         // 微信商户号
-	OutTradeNo   string `json:"out_trade_no"`  // 商户订单号
-	OutRefundNo  string `json:"out_refund_no"` // 商户退款单号
-	RefundID     string `json:"refund_id"`     // 微信退款订单号
-	RefundStatus string `json:"refund_status"` // 退款状态
-	RefundTime   string `json:"refund_time"`   // 退款完成时间
-	RefundAmount int64  `json:"refund_amount"` // 退款金额
-	OrderAmount  int64  `json:"order_amount"`  // 订单金额
-	RefundFrom   string `json:"refund_from"`   // 退款来源
-	RefundReason string `json:"refund_reason"` // 退款原因
-	Description  string `json:"description"`   // 退款描述
-	Env          int    `json:"env"`           // 订单环境
+	ToUserName   string       `json:"ToUserName"`    // 公众号/小程序ID
+	FromUserName string       `json:"FromUserName"`  // 用户OpenID
+	CreateTime   UnixTime     `json:"CreateTime"`    // 创建时间
+	MsgType      string       `json:"MsgType"`       // 消息类型
+	Event        string       `json:"Event"`         // 事件类型 retail_refund_notify
+	AppID        string       `json:"appid"`         // 小程序ID
+	Mchid        string       `json:"mchid"`         // 微信商户号
+	OutTradeNo   string       `json:"out_trade_no"`  // 商户订单号
+	OutRefundNo  string       `json:"out_refund_no"` // 商户退款单号
+	RefundID     string       `json:"refund_id"`     // 微信退款订单号
+	RefundStatus RefundStatus `json:"refund_status"` // 退款状态
+	RefundTime   Time         `json:"refund_time"`   // 退款完成时间
+	RefundAmount Money        `json:"refund_amount"` // 退款金额
+	OrderAmount  Money        `json:"order_amount"`  // 订单金额
+	RefundFrom   string       `json:"refund_from"`   // 退款来源
+	RefundReason string       `json:"refund_reason"` // 退款原因
+	Description  string       `json:"description"`   // 退款描述
+	Env          int          `json:"env"`           // 订单环境
+}
+
+// WithdrawNotify 表示提现结果通知的 JSON 结构体。
+type WithdrawNotify struct {
+	ToUserName     string         `json:"ToUserName"`            // 公众号/小程序ID
+	FromUserName   string         `json:"FromUserName"`          // 发送方
+	CreateTime     UnixTime       `json:"CreateTime"`            // 创建时间
+	MsgType        string         `json:"MsgType"`               // 消息类型 固定：event
+	Event          string         `json:"Event"`                 // 事件类型 固定：retail_withdraw_notify
+	AppID          string         `json:"appid"`                 // 小程序ID
+	Mchid          string         `json:"mchid"`                 // 微信商户号
+	OutWithdrawNo  string         `json:"out_withdraw_no"`       // 商户提现单号
+	WithdrawAmount Money          `json:"withdraw_amount"`       // 提现金额
+	Status         WithdrawStatus `json:"status"`                // 提现状态
+	FailReason     string         `json:"fail_reason,omitempty"` // 失败原因
+	FinishTime     Time           `json:"finish_time,omitzero"`  // 提现完成时间
+}
+
+// ProfitSharingNotify 表示分账结果通知的 JSON 结构体。
+type ProfitSharingNotify struct {
+	ToUserName      string             `json:"ToUserName"`       // 公众号/小程序ID
+	FromUserName    string             `json:"FromUserName"`     // 发送方
+	CreateTime      UnixTime           `json:"CreateTime"`       // 创建时间
+	MsgType         string             `json:"MsgType"`          // 消息类型 固定：event
+	Event           string             `json:"Event"`            // 事件类型 固定：retail_profit_sharing_notify
+	AppID           string             `json:"appid"`            // 小程序ID
+	Mchid           string             `json:"mchid"`            // 微信商户号
+	OutTradeNo      string             `json:"out_trade_no"`     // 商户支付订单号
+	ReceiverType    ProfitReceiverType `json:"receiver_type"`    // 分账接收方类型
+	ReceiverAccount string             `json:"receiver_account"` // 分账接收方账号
+	ProfitFee       Money              `json:"profit_fee"`       // 分账金额
+	OrderStatus     ProfitStatus       `json:"order_status"`     // 分账状态 1：初始化 2：成功 3：失败
+	FailReason      string             `json:"fail_reason"`      // 失败原因
+}
+
+// ProfitSharingReturnNotify 表示分账回退结果通知的 JSON 结构体。
+type ProfitSharingReturnNotify struct {
+	ToUserName   string             `json:"ToUserName"`    // 公众号/小程序ID
+	FromUserName string             `json:"FromUserName"`  // 发送方
+	CreateTime   UnixTime           `json:"CreateTime"`    // 创建时间
+	MsgType      string             `json:"MsgType"`       // 消息类型 固定：event
+	Event        string             `json:"Event"`         // 事件类型 固定：retail_profit_sharing_return_notify
+	AppID        string             `json:"appid"`         // 小程序ID
+	Mchid        string             `json:"mchid"`         // 微信商户号
+	OutTradeNo   string             `json:"out_trade_no"`  // 商户支付订单号
+	OutReturnNo  string             `json:"out_return_no"` // 商户回退单号
+	PayeeType    ProfitReceiverType `json:"payee_type"`    // 回退分账方类型
+	PayeeID      string             `json:"payee_id"`      // 回退分账方账号
+	RefundAmount Money              `json:"refund_amount"` // 回退金额
+	OrderStatus  ProfitReturnStatus `json:"order_status"`  // 回退状态 1：分账退回中 2：分账退回完成 3：分账退回失败
+	FailReason   string             `json:"fail_reason"`   // 失败原因
+}
+
+// MerchantRegisterNotify 表示商户进件状态变更通知的 JSON 结构体。
+type MerchantRegisterNotify struct {
+	ToUserName         string         `json:"ToUserName"`                     // 公众号/小程序ID
+	FromUserName       string         `json:"FromUserName"`                   // 发送方
+	CreateTime         UnixTime       `json:"CreateTime"`                     // 创建时间
+	MsgType            string         `json:"MsgType"`                        // 消息类型 固定：event
+	Event              string         `json:"Event"`                          // 事件类型 固定：retail_mch_register_notify
+	AppID              string         `json:"appid"`                          // 小程序ID
+	OutRegistrationID  string         `json:"out_registration_id"`            // 进件申请单号
+	ApplymentState     ApplymentState `json:"applyment_state"`                // 申请单状态
+	ApplymentStateDesc string         `json:"applyment_state_desc,omitempty"` // 申请单状态描述
+	SubMchid           string         `json:"sub_mchid,omitempty"`            // 进件成功后的商户号
+}
+
+// notifyHeader 用于识别推送事件类型。
+type notifyHeader struct {
+	MsgType string `json:"MsgType"`
+	Event   string `json:"Event"`
+}
+
+// ParseNotifyEvent 读取推送消息中的事件类型。
+func ParseNotifyEvent(body []byte) (NotifyEvent, error) {
+	var h notifyHeader
+	if err := json.Unmarshal(body, &h); err != nil {
+		return "", err
+	}
+	if h.Event == "" {
+		return "", errors.New("notify event is empty")
+	}
+	return NotifyEvent(h.Event), nil
+}
+
+// ParsePaymentNotify 解析支付成功通知。
+func ParsePaymentNotify(body []byte) (*PaymentNotify, error) {
+	var n PaymentNotify
+	if err := json.Unmarshal(body, &n); err != nil {
+		return nil, err
+	}
+	return &n, nil
+}
+
+// ParseRefundNotify 解析退款结果通知。
+func ParseRefundNotify(body []byte) (*RefundNotify, error) {
+	var n RefundNotify
+	if err := json.Unmarshal(body, &n); err != nil {
+		return nil, err
+	}
+	return &n, nil
+}
+
+// ParseWithdrawNotify 解析提现结果通知。
+func ParseWithdrawNotify(body []byte) (*WithdrawNotify, error) {
+	var n WithdrawNotify
+	if err := json.Unmarshal(body, &n); err != nil {
+		return nil, err
+	}
+	return &n, nil
+}
+
+// ParseProfitSharingNotify 解析分账结果通知。
+func ParseProfitSharingNotify(body []byte) (*ProfitSharingNotify, error) {
+	var n ProfitSharingNotify
+	if err := json.Unmarshal(body, &n); err != nil {
+		return nil, err
+	}
+	return &n, nil
+}
+
+// ParseProfitSharingReturnNotify 解析分账回退结果通知。
+func ParseProfitSharingReturnNotify(body []byte) (*ProfitSharingReturnNotify, error) {
+	var n ProfitSharingReturnNotify
+	if err := json.Unmarshal(body, &n); err != nil {
+		return nil, err
+	}
+	return &n, nil
+}
+
+// ParseMerchantRegisterNotify 解析商户进件状态变更通知。
+func ParseMerchantRegisterNotify(body []byte) (*MerchantRegisterNotify, error) {
+	var n MerchantRegisterNotify
+	if err := json.Unmarshal(body, &n); err != nil {
+		return nil, err
+	}
+	return &n, nil
 }
@@ -0,0 +1,142 @@
+package model
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// ErrUnhandledEvent 表示推送事件未注册处理函数。
+var ErrUnhandledEvent = errors.New("notify event is not handled")
+
+// NotifyRouter 按事件类型将推送消息分发给对应的处理函数。
+// 通过 PayStatusOf、RefundStatusOf 提供业务侧当前状态后，支付与退款通知在调用处理函数前按 Transition、TransitionRefund 校验，
+// 乱序通知（如已退款后又收到支付成功）不会交给处理函数，Dispatch 返回包装了 ErrInvalidTransition 的错误，
+// 调用方可用 errors.Is 判断后直接应答成功；未知状态照常交给处理函数。
+type NotifyRouter struct {
+	handlers     map[NotifyEvent]func(ctx context.Context, body []byte) error
+	payStatus    func(ctx context.Context, n *PaymentNotify) (PayStatus, error)
+	refundStatus func(ctx context.Context, n *RefundNotify) (RefundStatus, error)
+}
+
+// NewNotifyRouter 创建推送消息路由。
+func NewNotifyRouter() *NotifyRouter {
+	return &NotifyRouter{handlers: make(map[NotifyEvent]func(ctx context.Context, body []byte) error)}
+}
+
+// Handle 注册原始消息体的处理函数，用于未内置的事件类型。
+func (r *NotifyRouter) Handle(event NotifyEvent, h func(ctx context.Context, body []byte) error) {
+	r.handlers[event] = h
+}
+
+// PayStatusOf 设置查询业务侧订单当前支付状态的函数，订单尚无记录时返回空状态。
+func (r *NotifyRouter) PayStatusOf(f func(ctx context.Context, n *PaymentNotify) (PayStatus, error)) {
+	r.payStatus = f
+}
+
+// RefundStatusOf 设置查询业务侧退款当前状态的函数，退款尚无记录时返回空状态。
+func (r *NotifyRouter) RefundStatusOf(f func(ctx context.Context, n *RefundNotify) (RefundStatus, error)) {
+	r.refundStatus = f
+}
+
+// OnPayment 注册支付成功通知的处理函数。
+func (r *NotifyRouter) OnPayment(h func(ctx context.Context, n *PaymentNotify) error) {
+	r.Handle(EventPayment, func(ctx context.Context, body []byte) error {
+		n, err := ParsePaymentNotify(body)
+		if err != nil {
+			return err
+		}
+		if r.payStatus != nil {
+			from, err := r.payStatus(ctx, n)
+			if err != nil {
+				return err
+			}
+			if from != "" {
+				if err := Transition(from, n.PayStatus); errors.Is(err, ErrInvalidTransition) {
+					return fmt.Errorf("payment notify out_trade_no %s: %w", n.OutTradeNo, err)
+				}
+			}
+		}
+		return h(ctx, n)
+	})
+}
+
+// OnRefund 注册退款结果通知的处理函数。
+func (r *NotifyRouter) OnRefund(h func(ctx context.Context, n *RefundNotify) error) {
+	r.Handle(EventRefund, func(ctx context.Context, body []byte) error {
+		n, err := ParseRefundNotify(body)
+		if err != nil {
+			return err
+		}
+		if r.refundStatus != nil {
+			from, err := r.refundStatus(ctx, n)
+			if err != nil {
+				return err
+			}
+			if from != "" {
+				if err := TransitionRefund(from, n.RefundStatus); errors.Is(err, ErrInvalidTransition) {
+					return fmt.Errorf("refund notify out_refund_no %s: %w", n.OutRefundNo, err)
+				}
+			}
+		}
+		return h(ctx, n)
+	})
+}
+
+// OnWithdraw 注册提现结果通知的处理函数。
+func (r *NotifyRouter) OnWithdraw(h func(ctx context.Context, n *WithdrawNotify) error) {
+	r.Handle(EventWithdraw, func(ctx context.Context, body []byte) error {
+		n, err := ParseWithdrawNotify(body)
+		if err != nil {
+			return err
+		}
+		return h(ctx, n)
+	})
+}
+
+// OnProfitSharing 注册分账结果通知的处理函数。
+func (r *NotifyRouter) OnProfitSharing(h func(ctx context.Context, n *ProfitSharingNotify) error) {
+	r.Handle(EventProfitSharing, func(ctx context.Context, body []byte) error {
+		n, err := ParseProfitSharingNotify(body)
+		if err != nil {
+			return err
+		}
+		return h(ctx, n)
+	})
+}
+
+// OnProfitSharingReturn 注册分账回退结果通知的处理函数。
+func (r *NotifyRouter) OnProfitSharingReturn(h func(ctx context.Context, n *ProfitSharingReturnNotify) error) {
+	r.Handle(EventProfitSharingReturn, func(ctx context.Context, body []byte) error {
+		n, err := ParseProfitSharingReturnNotify(body)
+		if err != nil {
+			return err
+		}
+		return h(ctx, n)
+	})
+}
+
+// OnMerchantRegister 注册商户进件状态变更通知的处理函数。
+func (r *NotifyRouter) OnMerchantRegister(h func(ctx context.Context, n *MerchantRegisterNotify) error) {
+	r.Handle(EventMerchantRegister, func(ctx context.Context, body []byte) error {
+		n, err := ParseMerchantRegisterNotify(body)
+		if err != nil {
+			return err
+		}
+		return h(ctx, n)
+	})
+}
+
+// Dispatch 解析消息体中的事件类型并调用对应的处理函数。
+// 未注册的事件返回 ErrUnhandledEvent。
+func (r *NotifyRouter) Dispatch(ctx context.Context, body []byte) error {
+	event, err := ParseNotifyEvent(body)
+	if err != nil {
+		return err
+	}
+	h, ok := r.handlers[event]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrUnhandledEvent, event)
+	}
+	return h(ctx, body)
+}
@@ -1,5 +1,10 @@
 package model
 
+import (
+	"errors"
+	"fmt"
+)
+
 // Env 表示环境。
 type Env int
 
@@ -10,6 +15,13 @@ const (
 	EnvSandbox Env = 1
 )
 
+var (
+	// ErrUnknownStatus 表示状态值不在已知枚举内。
+	ErrUnknownStatus = errors.New("unknown status")
+	// ErrInvalidTransition 表示状态流转不合法。
+	ErrInvalidTransition = errors.New("invalid status transition")
+)
+
 // PayStatus 表示支付状态。
 type PayStatus string
 
@@ -27,3 +39,59 @@ const (
 	// PayStatusRefunded 已退款
 	PayStatusRefunded PayStatus = "ORDER_REFUND"
 )
+
+// payStatusTransitions 记录每个支付状态允许流转到的下一状态。
+var payStatusTransitions = map[PayStatus][]PayStatus{
+	PayStatusInit:      {PayStatusPrePay, PayStatusClosed},
+	PayStatusPrePay:    {PayStatusSuccess, PayStatusClosed},
+	PayStatusSuccess:   {PayStatusRefunding, PayStatusRefunded},
+	PayStatusRefunding: {PayStatusRefunded, PayStatusSuccess}, // 退款失败时回到支付成功
+	PayStatusClosed:    nil,
+	PayStatusRefunded:  nil,
+}
+
+// IsKnown 判断是否为已知的支付状态。
+func (s PayStatus) IsKnown() bool {
+	_, ok := payStatusTransitions[s]
+	return ok
+}
+
+// IsTerminal 判断是否为终态（已关闭或已退款）。
+func (s PayStatus) IsTerminal() bool {
+	return s == PayStatusClosed || s == PayStatusRefunded
+}
+
+// IsPaid 判断订单是否已完成支付（含退款中、已退款）。
+func (s PayStatus) IsPaid() bool {
+	return s == PayStatusSuccess || s == PayStatusRefunding || s == PayStatusRefunded
+}
+
+// CanClose 判断订单是否可以关闭。
+func (s PayStatus) CanClose() bool {
+	return s == PayStatusInit || s == PayStatusPrePay
+}
+
+// CanRefund 判断订单是否可以发起退款。
+func (s PayStatus) CanRefund() bool {
+	return s == PayStatusSuccess || s == PayStatusRefunding
+}
+
+// Transition 校验支付状态从 from 流转到 to 是否合法。
+// 相同状态视为重复通知，返回 nil；未知状态返回包装了 ErrUnknownStatus 的错误。
+func Transition(from, to PayStatus) error {
+	if !from.IsKnown() {
+		return fmt.Errorf("%w: pay_status %q", ErrUnknownStatus, from)
+	}
+	if !to.IsKnown() {
+		return fmt.Errorf("%w: pay_status %q", ErrUnknownStatus, to)
+	}
+	if from == to {
+		return nil
+	}
+	for _, next := range payStatusTransitions[from] {
+		if next == to {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: pay_status %s -> %s", ErrInvalidTransition, from, to)
+}
@@ -1,6 +1,9 @@
 package model
 
+import "strconv"
+
 // ProfitReceiverType 分账接收方类型。
+// 接口要求与添加分账方时填入的内容一致，常量取值未经接口文档确认，SDK 只校验非空，不拒绝其它取值。
 type ProfitReceiverType string
 
 const (
@@ -10,7 +13,7 @@ const (
 	ProfitReceiverIndividual ProfitReceiverType = "INDIVIDUAL"
 )
 
-// ProfitReceiverRelationType 分账接收方关系类型。
+// ProfitReceiverRelationType 分账接收方关系类型，与 ProfitReceiverType 相同，SDK 只校验非空。
 type ProfitReceiverRelationType string
 
 const (
@@ -24,35 +27,81 @@ const (
 	RelationOther ProfitReceiverRelationType = "OTHER"
 )
 
-// ProfitReceiver 分账接收方信息。
+// ProfitReceiver 分账接收方信息，ProfitPlan 的分账结果与 ProfitOrchestrator 的输入均使用该结构。
 type ProfitReceiver struct {
-	Type      ProfitReceiverType        `json:"type"`                 // 分账接收方类型
-	Account   string                    `json:"account"`              // 分账接收方账号
-	Amount    int64                     `json:"amount"`               // 分账金额
-	Desc      string                    `json:"desc,omitempty"`       // 分账描述
-	Relation  ProfitReceiverRelationType `json:"relation"`             // 分账接收方关系
+	Type     ProfitReceiverType         `json:"type"`           // 分账接收方类型
+	Account  string                     `json:"account"`        // 分账接收方账号
+	Amount   Money                      `json:"amount"`         // 分账金额
+	Desc     string                     `json:"desc,omitempty"` // 分账描述
+	Relation ProfitReceiverRelationType `json:"relation"`       // 分账接收方关系
 }
 
-// ProfitStatus 分账状态。
-type ProfitStatus string
+// ProfitStatus 分账状态，对应 QueryProfitSharing 与分账结果通知中的 order_status。
+type ProfitStatus int
 
 const (
-	// ProfitStatusInit 初始化
-	ProfitStatusInit ProfitStatus = "PROFIT_SHARING"
-	// ProfitStatusFinished 已分账完成
-	ProfitStatusFinished ProfitStatus = "PROFIT_FINISHED"
-	// ProfitStatusClosed 已关闭
-	ProfitStatusClosed ProfitStatus = "PROFIT_CLOSED"
+	// ProfitStatusInit 初始化（处理中）
+	ProfitStatusInit ProfitStatus = 1
+	// ProfitStatusSuccess 分账成功
+	ProfitStatusSuccess ProfitStatus = 2
+	// ProfitStatusFailed 分账失败
+	ProfitStatusFailed ProfitStatus = 3
 )
 
-// ProfitReturnStatus 分账回退状态。
-type ProfitReturnStatus string
+// IsKnown 判断是否为已定义的分账状态。
+func (s ProfitStatus) IsKnown() bool {
+	return s >= ProfitStatusInit && s <= ProfitStatusFailed
+}
+
+// IsTerminal 判断是否为终态（成功或失败）。
+func (s ProfitStatus) IsTerminal() bool {
+	return s == ProfitStatusSuccess || s == ProfitStatusFailed
+}
+
+// String 返回分账状态名称。
+func (s ProfitStatus) String() string {
+	switch s {
+	case ProfitStatusInit:
+		return "INIT"
+	case ProfitStatusSuccess:
+		return "SUCCESS"
+	case ProfitStatusFailed:
+		return "FAILED"
+	}
+	return "ProfitStatus(" + strconv.Itoa(int(s)) + ")"
+}
+
+// ProfitReturnStatus 分账回退状态，对应 QueryProfitSharingReturn 与分账回退结果通知中的 order_status。
+type ProfitReturnStatus int
 
 const (
-	// ProfitReturnPending 待回退
-	ProfitReturnPending ProfitReturnStatus = "RETURN_PENDING"
-	// ProfitReturnSuccess 回退成功
-	ProfitReturnSuccess ProfitReturnStatus = "RETURN_SUCCESS"
-	// ProfitReturnFailed 回退失败
-	ProfitReturnFailed ProfitReturnStatus = "RETURN_FAILED"
+	// ProfitReturnProcessing 分账退回中
+	ProfitReturnProcessing ProfitReturnStatus = 1
+	// ProfitReturnSuccess 分账退回完成
+	ProfitReturnSuccess ProfitReturnStatus = 2
+	// ProfitReturnFailed 分账退回失败
+	ProfitReturnFailed ProfitReturnStatus = 3
 )
+
+// IsKnown 判断是否为已定义的分账回退状态。
+func (s ProfitReturnStatus) IsKnown() bool {
+	return s >= ProfitReturnProcessing && s <= ProfitReturnFailed
+}
+
+// IsTerminal 判断是否为终态（完成或失败）。
+func (s ProfitReturnStatus) IsTerminal() bool {
+	return s == ProfitReturnSuccess || s == ProfitReturnFailed
+}
+
+// String 返回分账回退状态名称。
+func (s ProfitReturnStatus) String() string {
+	switch s {
+	case ProfitReturnProcessing:
+		return "PROCESSING"
+	case ProfitReturnSuccess:
+		return "SUCCESS"
+	case ProfitReturnFailed:
+		return "FAILED"
+	}
+	return "ProfitReturnStatus(" + strconv.Itoa(int(s)) + ")"
+}
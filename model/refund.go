@@ -1,5 +1,7 @@
 package model
 
+import "fmt"
+
 // RefundStatus 表示退款状态。
 type RefundStatus string
 
@@ -13,3 +15,41 @@ const (
 	// RefundFail 失败
 	RefundFail RefundStatus = "REFUND_FAIL"
 )
+
+// refundStatusTransitions 记录每个退款状态允许流转到的下一状态。
+var refundStatusTransitions = map[RefundStatus][]RefundStatus{
+	RefundInit:       {RefundProcessing, RefundSuccess, RefundFail},
+	RefundProcessing: {RefundSuccess, RefundFail},
+	RefundSuccess:    nil,
+	RefundFail:       nil,
+}
+
+// IsKnown 判断是否为已知的退款状态。
+func (s RefundStatus) IsKnown() bool {
+	_, ok := refundStatusTransitions[s]
+	return ok
+}
+
+// IsTerminal 判断是否为终态（成功或失败）。
+func (s RefundStatus) IsTerminal() bool {
+	return s == RefundSuccess || s == RefundFail
+}
+
+// TransitionRefund 校验退款状态从 from 流转到 to 是否合法，规则同 Transition。
+func TransitionRefund(from, to RefundStatus) error {
+	if !from.IsKnown() {
+		return fmt.Errorf("%w: refund_status %q", ErrUnknownStatus, from)
+	}
+	if !to.IsKnown() {
+		return fmt.Errorf("%w: refund_status %q", ErrUnknownStatus, to)
+	}
+	if from == to {
+		return nil
+	}
+	for _, next := range refundStatusTransitions[from] {
+		if next == to {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: refund_status %s -> %s", ErrInvalidTransition, from, to)
+}
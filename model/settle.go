@@ -0,0 +1,34 @@
+package model
+
+import "strconv"
+
+// SettleStatus 表示订单结算状态。
+type SettleStatus int
+
+const (
+	// SettleStatusUnsettled 未结算
+	SettleStatusUnsettled SettleStatus = 0
+	// SettleStatusSettled 已结算
+	SettleStatusSettled SettleStatus = 1
+)
+
+// IsKnown 判断是否为已知的结算状态。
+func (s SettleStatus) IsKnown() bool {
+	return s == SettleStatusUnsettled || s == SettleStatusSettled
+}
+
+// IsSettled 判断订单是否已结算。
+func (s SettleStatus) IsSettled() bool {
+	return s == SettleStatusSettled
+}
+
+// String 返回结算状态名称。
+func (s SettleStatus) String() string {
+	switch s {
+	case SettleStatusUnsettled:
+		return "UNSETTLED"
+	case SettleStatusSettled:
+		return "SETTLED"
+	}
+	return "SettleStatus(" + strconv.Itoa(int(s)) + ")"
+}
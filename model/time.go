@@ -0,0 +1,175 @@
+package model
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ShanghaiLocation 微信接口时间使用的时区（UTC+8）。
+var ShanghaiLocation = time.FixedZone("Asia/Shanghai", 8*3600)
+
+// DateTimeLayout 微信接口常用的 yyyy-MM-dd HH:mm:ss 时间格式。
+const DateTimeLayout = "2006-01-02 15:04:05"
+
+// timeFormat 记录时间字段在 JSON 中的原始格式。
+type timeFormat uint8
+
+const (
+	timeFormatDateTime    timeFormat = iota // yyyy-MM-dd HH:mm:ss 字符串（默认）
+	timeFormatRFC3339                       // RFC3339 字符串
+	timeFormatEpochString                   // 秒级时间戳字符串
+	timeFormatEpochNumber                   // 秒级时间戳数字
+)
+
+// Time 表示微信接口中的时间字段。
+// 反序列化兼容 RFC3339、yyyy-MM-dd HH:mm:ss（按 Asia/Shanghai）和秒级时间戳（字符串或数字），
+// 序列化时按原始格式输出；时间未被修改时原样输出原始字符串。
+type Time struct {
+	time.Time
+	format timeFormat
+	raw    string // 原始字符串，用于无损回写
+}
+
+// NewTime 创建以 yyyy-MM-dd HH:mm:ss 格式序列化的时间。
+func NewTime(t time.Time) Time {
+	return Time{Time: t}
+}
+
+// ParseTime 解析微信接口返回的时间，支持 RFC3339、yyyy-MM-dd HH:mm:ss（按 Asia/Shanghai）以及秒级时间戳。
+func ParseTime(s string) (time.Time, error) {
+	t, _, err := parseTime(s)
+	return t, err
+}
+
+func parseTime(s string) (time.Time, timeFormat, error) {
+	str := strings.TrimSpace(s)
+	if str == "" {
+		return time.Time{}, timeFormatDateTime, fmt.Errorf("time is empty")
+	}
+	if t, err := time.Parse(time.RFC3339, str); err == nil {
+		return t, timeFormatRFC3339, nil
+	}
+	if t, err := time.ParseInLocation(DateTimeLayout, str, ShanghaiLocation); err == nil {
+		return t, timeFormatDateTime, nil
+	}
+	if sec, err := strconv.ParseInt(str, 10, 64); err == nil {
+		return time.Unix(sec, 0).In(ShanghaiLocation), timeFormatEpochString, nil
+	}
+	return time.Time{}, timeFormatDateTime, fmt.Errorf("invalid time %q", s)
+}
+
+// IsZero 判断时间是否为零值，字段使用 omitzero 时零值不输出。
+func (t Time) IsZero() bool {
+	return t.Time.IsZero()
+}
+
+// String 以 yyyy-MM-dd HH:mm:ss（Asia/Shanghai）格式返回时间，零值返回空字符串。
+func (t Time) String() string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.In(ShanghaiLocation).Format(DateTimeLayout)
+}
+
+// MarshalJSON 按原始格式序列化时间。
+func (t Time) MarshalJSON() ([]byte, error) {
+	if t.format == timeFormatEpochNumber {
+		if t.IsZero() {
+			return []byte("0"), nil
+		}
+		return []byte(strconv.FormatInt(t.Unix(), 10)), nil
+	}
+	if t.IsZero() {
+		return []byte(strconv.Quote(t.raw)), nil
+	}
+	if t.raw != "" {
+		if parsed, _, err := parseTime(t.raw); err == nil && parsed.Equal(t.Time) {
+			return []byte(strconv.Quote(t.raw)), nil
+		}
+	}
+
+	var s string
+	switch t.format {
+	case timeFormatRFC3339:
+		s = t.Format(time.RFC3339)
+	case timeFormatEpochString:
+		s = strconv.FormatInt(t.Unix(), 10)
+	default:
+		s = t.In(ShanghaiLocation).Format(DateTimeLayout)
+	}
+	return []byte(strconv.Quote(s)), nil
+}
+
+// UnmarshalJSON 解析字符串或数字形式的时间，空字符串、0 与 null 解析为零值。
+func (t *Time) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if bytes.Equal(data, []byte("null")) {
+		*t = Time{}
+		return nil
+	}
+
+	if len(data) > 0 && data[0] == '"' {
+		s, err := strconv.Unquote(string(data))
+		if err != nil {
+			return err
+		}
+		if strings.TrimSpace(s) == "" {
+			*t = Time{raw: s}
+			return nil
+		}
+		parsed, format, err := parseTime(s)
+		if err != nil {
+			return err
+		}
+		*t = Time{Time: parsed, format: format, raw: s}
+		return nil
+	}
+
+	sec, err := strconv.ParseInt(string(data), 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid time %s: %w", data, err)
+	}
+	if sec == 0 {
+		*t = Time{format: timeFormatEpochNumber}
+		return nil
+	}
+	*t = Time{Time: time.Unix(sec, 0).In(ShanghaiLocation), format: timeFormatEpochNumber}
+	return nil
+}
+
+// UnixTime 表示以秒级时间戳数字收发的时间，如推送消息中的 CreateTime，零值序列化为 0。
+// 反序列化同样兼容 Time 支持的字符串格式。
+type UnixTime struct {
+	time.Time
+}
+
+// NewUnixTime 创建以秒级时间戳数字序列化的时间。
+func NewUnixTime(t time.Time) UnixTime {
+	return UnixTime{Time: t}
+}
+
+// String 以 yyyy-MM-dd HH:mm:ss（Asia/Shanghai）格式返回时间，零值返回空字符串。
+func (t UnixTime) String() string {
+	return Time{Time: t.Time}.String()
+}
+
+// MarshalJSON 序列化为秒级时间戳数字。
+func (t UnixTime) MarshalJSON() ([]byte, error) {
+	if t.IsZero() {
+		return []byte("0"), nil
+	}
+	return strconv.AppendInt(nil, t.Unix(), 10), nil
+}
+
+// UnmarshalJSON 解析数字或字符串形式的时间，空字符串、0 与 null 解析为零值。
+func (t *UnixTime) UnmarshalJSON(data []byte) error {
+	var v Time
+	if err := v.UnmarshalJSON(data); err != nil {
+		return err
+	}
+	*t = UnixTime{Time: v.Time}
+	return nil
+}
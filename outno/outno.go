@@ -0,0 +1,189 @@
+// Package outno 生成商户侧单号（out_trade_no、out_refund_no、out_withdraw_no、out_return_no 等）。
+//
+// 单号格式为 {prefix}{yyyyMMddHHmmssSSS}{node:3}{seq:4}，时间部分使用 Asia/Shanghai，
+// 总长度为 24 + len(prefix)，按生成时间有序，同一节点同一毫秒内最多生成 10000 个。
+package outno
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// MinLength 单号最小长度。
+	MinLength = 6
+	// MaxLength 单号最大长度。
+	MaxLength = 32
+	// MaxPrefixLength 前缀最大长度。
+	MaxPrefixLength = MaxLength - bodyLength
+	// MaxNode 节点号最大值。
+	MaxNode = 999
+
+	timeLayout  = "20060102150405"
+	bodyLength  = 17 + 3 + 4
+	maxSequence = 9999
+	maxDeriveN  = 99
+)
+
+var location = time.FixedZone("Asia/Shanghai", 8*3600)
+
+// Info 表示从单号中解析出的信息。
+type Info struct {
+	Prefix string
+	Time   time.Time
+	Node   int
+	Seq    int
+}
+
+// Options 生成器参数。
+type Options struct {
+	Prefix string // 单号前缀，最长 8 位，字符集同单号
+	Node   int    // 节点号 1-999，必填，多实例部署时需保证各实例不同；小于 0 时随机生成
+}
+
+// Generator 生成按时间有序、不重复的单号，可并发使用。
+type Generator struct {
+	prefix string
+	node   int
+	now    func() time.Time
+
+	mu     sync.Mutex
+	lastMs int64
+	seq    int
+}
+
+// NewGenerator 创建单号生成器。
+func NewGenerator(opts Options) (*Generator, error) {
+	if len(opts.Prefix) > MaxPrefixLength {
+		return nil, fmt.Errorf("prefix must be at most %d characters", MaxPrefixLength)
+	}
+	if err := checkCharset(opts.Prefix); err != nil {
+		return nil, err
+	}
+	if opts.Node == 0 || opts.Node > MaxNode {
+		// 零值节点号通常意味着未配置，多实例都使用 0 会生成重复单号。
+		return nil, fmt.Errorf("node must be between 1 and %d, or negative for random", MaxNode)
+	}
+	node := opts.Node
+	if node < 0 {
+		n, err := rand.Int(rand.Reader, big.NewInt(MaxNode))
+		if err != nil {
+			return nil, err
+		}
+		node = int(n.Int64()) + 1
+	}
+	return &Generator{prefix: opts.Prefix, node: node, now: time.Now}, nil
+}
+
+// Node 返回生成器使用的节点号。
+func (g *Generator) Node() int {
+	return g.node
+}
+
+// Next 生成一个新单号。
+func (g *Generator) Next() string {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	ms := g.now().UnixMilli()
+	if ms < g.lastMs {
+		// 时钟回拨时沿用上次的时间，保证单号单调递增。
+		ms = g.lastMs
+	}
+	if ms == g.lastMs {
+		g.seq++
+		for g.seq > maxSequence {
+			time.Sleep(time.Millisecond)
+			if now := g.now().UnixMilli(); now > g.lastMs {
+				ms, g.seq = now, 0
+			}
+		}
+	} else {
+		g.seq = 0
+	}
+	g.lastMs = ms
+
+	t := time.UnixMilli(ms).In(location)
+	return fmt.Sprintf("%s%s%03d%03d%04d", g.prefix, t.Format(timeLayout), t.Nanosecond()/int(time.Millisecond), g.node, g.seq)
+}
+
+// Parse 解析由 Generator 生成的单号。
+func Parse(id string) (Info, error) {
+	if err := Validate(id); err != nil {
+		return Info{}, err
+	}
+	if len(id) < bodyLength {
+		return Info{}, errors.New("id is too short to be generated by outno")
+	}
+	prefix, body := id[:len(id)-bodyLength], id[len(id)-bodyLength:]
+	for _, c := range body {
+		if c < '0' || c > '9' {
+			return Info{}, errors.New("id is not generated by outno")
+		}
+	}
+	t, err := time.ParseInLocation(timeLayout, body[:14], location)
+	if err != nil {
+		return Info{}, fmt.Errorf("id is not generated by outno: %w", err)
+	}
+	ms, _ := strconv.Atoi(body[14:17])
+	node, _ := strconv.Atoi(body[17:20])
+	seq, _ := strconv.Atoi(body[20:])
+	return Info{
+		Prefix: prefix,
+		Time:   t.Add(time.Duration(ms) * time.Millisecond),
+		Node:   node,
+		Seq:    seq,
+	}, nil
+}
+
+// Derive 由父单号确定性地派生第 n 个子单号（n 为 1-99），如由 out_trade_no 派生 out_refund_no。
+// 结果为 {parent}{tag}{n:2}；超过 32 位时改为 {tag}{sha256(parent) 前缀}{n:2}。
+func Derive(parent, tag string, n int) (string, error) {
+	if err := Validate(parent); err != nil {
+		return "", fmt.Errorf("parent: %w", err)
+	}
+	if tag == "" || len(tag) > 4 {
+		return "", errors.New("tag must be 1 to 4 characters")
+	}
+	if err := checkCharset(tag); err != nil {
+		return "", err
+	}
+	if n < 1 || n > maxDeriveN {
+		return "", fmt.Errorf("n must be between 1 and %d", maxDeriveN)
+	}
+
+	suffix := fmt.Sprintf("%s%02d", tag, n)
+	if len(parent)+len(suffix) <= MaxLength {
+		return parent + suffix, nil
+	}
+	sum := sha256.Sum256([]byte(parent))
+	digest := hex.EncodeToString(sum[:])
+	return tag + digest[:MaxLength-len(suffix)] + fmt.Sprintf("%02d", n), nil
+}
+
+// Validate 校验单号是否满足微信要求：6-32 位，仅包含数字、大小写字母和 _-|*@。
+func Validate(id string) error {
+	if len(id) < MinLength || len(id) > MaxLength {
+		return fmt.Errorf("id length must be between %d and %d", MinLength, MaxLength)
+	}
+	return checkCharset(id)
+}
+
+func checkCharset(s string) error {
+	for _, c := range s {
+		switch {
+		case c >= '0' && c <= '9', c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', strings.ContainsRune("_-|*@", c):
+		default:
+			return fmt.Errorf("invalid character %q", c)
+		}
+	}
+	return nil
+}
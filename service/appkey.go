@@ -0,0 +1,23 @@
+package service
+
+import (
+	"context"
+	"fmt"
+)
+
+// AppKeyResolver 按商户号返回对应的 appKey。
+type AppKeyResolver interface {
+	AppKey(ctx context.Context, mchid string) (string, error)
+}
+
+// AppKeyMap 以 map[mchid]appKey 实现 AppKeyResolver。
+type AppKeyMap map[string]string
+
+// AppKey 返回商户号对应的 appKey。
+func (m AppKeyMap) AppKey(ctx context.Context, mchid string) (string, error) {
+	appKey, ok := m[mchid]
+	if !ok || appKey == "" {
+		return "", fmt.Errorf("appKey not found for mchid %s", mchid)
+	}
+	return appKey, nil
+}
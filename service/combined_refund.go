@@ -0,0 +1,244 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+
+	"github.com/wneverfade/wechatpay-b2b/model"
+	"github.com/wneverfade/wechatpay-b2b/outno"
+	"github.com/wneverfade/wechatpay-b2b/types"
+)
+
+// Refunder 发起单笔退款，OrderService 与 RefundManager 均实现该接口。
+type Refunder interface {
+	CreateRefund(ctx context.Context, req types.RefundRequest, appKey string) (*types.RefundResponse, error)
+}
+
+// CombinedRefundRequest 合单退款参数。
+// TotalAmount 与 Items 二选一：TotalAmount 按子单 order_amount 比例分摊，Items 按子单 out_trade_no 指定金额。
+type CombinedRefundRequest struct {
+	SubOrders    []*types.CombinedOrder // 原合单子单，即 CombinedPaymentSignData.CombinedOrderList
+	OutRefundNo  string                 // 合单退款单号，子单退款单号由其派生为 {OutRefundNo}_{子单序号}，派生结果不能超过 32 位
+	TotalAmount  model.Money            // 退款总额
+	Items        map[string]model.Money // 子单 out_trade_no -> 退款金额
+	RefundFrom   int32
+	RefundReason int32
+	Description  string
+}
+
+// SubRefundResult 表示单个子单的退款结果。
+type SubRefundResult struct {
+	Mchid       string
+	OutTradeNo  string
+	OutRefundNo string
+	Amount      model.Money
+	Response    *types.RefundResponse
+	Err         error
+}
+
+// Succeeded 判断子单退款是否已成功受理。
+func (r SubRefundResult) Succeeded() bool {
+	return r.Err == nil && r.Response != nil
+}
+
+// CombinedRefundResult 表示合单退款的整体结果。
+type CombinedRefundResult struct {
+	Items []SubRefundResult
+}
+
+// Failed 返回未成功受理的子单退款。
+func (r *CombinedRefundResult) Failed() []SubRefundResult {
+	var out []SubRefundResult
+	for _, item := range r.Items {
+		if !item.Succeeded() {
+			out = append(out, item)
+		}
+	}
+	return out
+}
+
+// Err 汇总子单退款错误，全部成功时返回 nil。
+func (r *CombinedRefundResult) Err() error {
+	var errs []error
+	for _, item := range r.Failed() {
+		errs = append(errs, fmt.Errorf("out_trade_no %s: %w", item.OutTradeNo, item.Err))
+	}
+	return errors.Join(errs...)
+}
+
+// CombinedRefunder 将合单退款拆分到各子商户并分别发起退款。
+type CombinedRefunder struct {
+	refunder Refunder
+	appKeys  AppKeyResolver
+}
+
+// NewCombinedRefunder 创建合单退款器，refunder 可传入 OrderService 或 RefundManager。
+func NewCombinedRefunder(refunder Refunder, appKeys AppKeyResolver) *CombinedRefunder {
+	return &CombinedRefunder{refunder: refunder, appKeys: appKeys}
+}
+
+// Allocate 计算各子单的退款金额与派生的退款单号，不发起请求。退款金额为 0 的子单不会出现在结果中。
+func (r *CombinedRefunder) Allocate(req CombinedRefundRequest) ([]SubRefundResult, error) {
+	if req.OutRefundNo == "" {
+		return nil, errors.New("out_refund_no is required")
+	}
+	if len(req.SubOrders) == 0 {
+		return nil, errors.New("sub_orders is required")
+	}
+	if (req.TotalAmount > 0) == (len(req.Items) > 0) {
+		return nil, errors.New("exactly one of total_amount and items is required")
+	}
+
+	amounts := make([]model.Money, len(req.SubOrders))
+	for i, order := range req.SubOrders {
+		if order == nil || order.Mchid == "" || order.OutTradeNo == "" {
+			return nil, fmt.Errorf("sub_orders[%d]: mchid and out_trade_no are required", i)
+		}
+		amounts[i] = order.Amount.OrderAmount
+	}
+
+	var shares []model.Money
+	if req.TotalAmount > 0 {
+		var err error
+		if shares, err = allocateProportional(req.TotalAmount, amounts); err != nil {
+			return nil, err
+		}
+	} else {
+		shares = make([]model.Money, len(req.SubOrders))
+		matched := 0
+		for i, order := range req.SubOrders {
+			amount, ok := req.Items[order.OutTradeNo]
+			if !ok {
+				continue
+			}
+			matched++
+			if amount < 0 || amount > amounts[i] {
+				return nil, fmt.Errorf("refund amount %d of out_trade_no %s exceeds order_amount %d", amount, order.OutTradeNo, amounts[i])
+			}
+			shares[i] = amount
+		}
+		if matched != len(req.Items) {
+			return nil, errors.New("items contain out_trade_no not in sub_orders")
+		}
+	}
+
+	var out []SubRefundResult
+	for i, order := range req.SubOrders {
+		if shares[i] == 0 {
+			continue
+		}
+		// 保持 {OutRefundNo}_{序号} 格式不变，已发起的合单退款重试时才能复用相同的子单退款单号，避免重复退款。
+		outRefundNo := fmt.Sprintf("%s_%d", req.OutRefundNo, i+1)
+		if len(outRefundNo) > outno.MaxLength {
+			return nil, fmt.Errorf("out_refund_no: derived %s exceeds %d characters", outRefundNo, outno.MaxLength)
+		}
+		out = append(out, SubRefundResult{
+			Mchid:       order.Mchid,
+			OutTradeNo:  order.OutTradeNo,
+			OutRefundNo: outRefundNo,
+			Amount:      shares[i],
+		})
+	}
+	return out, nil
+}
+
+// Refund 按分摊结果向各子商户发起退款。单个子单失败不会中断其它子单，
+// 失败的子单可通过 Resume 使用相同的退款单号重试。
+func (r *CombinedRefunder) Refund(ctx context.Context, req CombinedRefundRequest) (*CombinedRefundResult, error) {
+	items, err := r.Allocate(req)
+	if err != nil {
+		return nil, err
+	}
+	result := &CombinedRefundResult{Items: items}
+	for i := range result.Items {
+		r.refundItem(ctx, req, &result.Items[i])
+	}
+	return result, result.Err()
+}
+
+// Resume 重新发起 prev 中失败的子单退款，已成功的子单保持不变。
+func (r *CombinedRefunder) Resume(ctx context.Context, req CombinedRefundRequest, prev *CombinedRefundResult) (*CombinedRefundResult, error) {
+	if prev == nil {
+		return r.Refund(ctx, req)
+	}
+	result := &CombinedRefundResult{Items: append([]SubRefundResult(nil), prev.Items...)}
+	for i := range result.Items {
+		if !result.Items[i].Succeeded() {
+			r.refundItem(ctx, req, &result.Items[i])
+		}
+	}
+	return result, result.Err()
+}
+
+// refundItem 发起单个子单退款并记录结果。
+func (r *CombinedRefunder) refundItem(ctx context.Context, req CombinedRefundRequest, item *SubRefundResult) {
+	if r.refunder == nil || r.appKeys == nil {
+		item.Err = errors.New("combined refunder is not initialized")
+		return
+	}
+	appKey, err := r.appKeys.AppKey(ctx, item.Mchid)
+	if err != nil {
+		item.Err = err
+		return
+	}
+	item.Response, item.Err = r.refunder.CreateRefund(ctx, types.RefundRequest{
+		Mchid:        item.Mchid,
+		OutTradeNo:   item.OutTradeNo,
+		OutRefundNo:  item.OutRefundNo,
+		RefundAmount: item.Amount,
+		RefundFrom:   req.RefundFrom,
+		RefundReason: req.RefundReason,
+		Description:  req.Description,
+	}, appKey)
+	if item.Err != nil {
+		item.Response = nil
+	}
+}
+
+// allocateProportional 按 weights 比例将 total 拆分为整数分，采用最大余数法保证各份之和等于 total，
+// 余数相同时序号小的优先。total 不能超过 weights 之和，每份不超过对应的 weight。
+func allocateProportional(total model.Money, weights []model.Money) ([]model.Money, error) {
+	if total < 0 {
+		return nil, errors.New("total amount must not be negative")
+	}
+	sum, err := model.SumMoney(weights...)
+	if err != nil {
+		return nil, err
+	}
+	for _, w := range weights {
+		if w < 0 {
+			return nil, errors.New("weights must not be negative")
+		}
+	}
+	if total > sum {
+		return nil, fmt.Errorf("total amount %d exceeds %d", total, sum)
+	}
+
+	shares := make([]model.Money, len(weights))
+	if total == 0 {
+		return shares, nil
+	}
+
+	type remainder struct {
+		index int
+		value *big.Int
+	}
+	rems := make([]remainder, len(weights))
+	bigTotal, bigSum := big.NewInt(int64(total)), big.NewInt(int64(sum))
+	var allocated model.Money
+	for i, w := range weights {
+		q, m := new(big.Int).QuoRem(new(big.Int).Mul(bigTotal, big.NewInt(int64(w))), bigSum, new(big.Int))
+		shares[i] = model.Money(q.Int64())
+		allocated += shares[i]
+		rems[i] = remainder{index: i, value: m}
+	}
+	sort.SliceStable(rems, func(a, b int) bool { return rems[a].value.Cmp(rems[b].value) > 0 })
+	for i := 0; allocated < total; i++ {
+		shares[rems[i].index]++
+		allocated++
+	}
+	return shares, nil
+}
@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"time"
 
 	"github.com/wneverfade/wechatpay-b2b/client"
 	"github.com/wneverfade/wechatpay-b2b/types"
@@ -24,6 +25,10 @@ type MerchantService interface {
 	Withdraw(ctx context.Context, req types.WithdrawRequest, appKey string) (*types.WithdrawResponse, error)
 	// QueryWithdraw 查询提现状态。
 	QueryWithdraw(ctx context.Context, req types.QueryWithdrawRequest, appKey string) (*types.QueryWithdrawResponse, error)
+	// RegisterMerchant 提交商户号进件申请。
+	RegisterMerchant(ctx context.Context, req types.RegisterMerchantRequest) (*types.RegisterMerchantResponse, error)
+	// GetMerchantOpenStatus 查询商户号进件与开通状态。
+	GetMerchantOpenStatus(ctx context.Context, req types.GetMerchantOpenStatusRequest) (*types.GetMerchantOpenStatusResponse, error)
 }
 
 type merchantService struct {
@@ -31,11 +36,13 @@ type merchantService struct {
 }
 
 const (
-	getMerchantInfoURI   = "/retail/B2b/getmchinfo"
-	getMerchantAppKeyURI = "/retail/B2b/getappkey"
-	getMchBalanceURI     = "/retail/B2b/getmchbalance"
-	withdrawURI          = "/retail/B2b/withdraw"
-	queryWithdrawURI     = "/retail/B2b/querywithdraw"
+	getMerchantInfoURI       = "/retail/B2b/getmchinfo"
+	getMerchantAppKeyURI     = "/retail/B2b/getappkey"
+	getMchBalanceURI         = "/retail/B2b/getmchbalance"
+	withdrawURI              = "/retail/B2b/withdraw"
+	queryWithdrawURI         = "/retail/B2b/querywithdraw"
+	registerMerchantURI      = "/retail/B2b/registermerchant"
+	getMerchantOpenStatusURI = "/retail/B2b/getmerchantopenstatus"
 )
 
 // NewMerchantService 创建商户信息服务。
@@ -272,3 +279,89 @@ func (s *merchantService) QueryWithdraw(ctx context.Context, req types.QueryWith
 	}
 	return &out, nil
 }
+
+// RegisterMerchant 提交商户号进件申请，提交前使用 ValidateRegisterMerchant 校验资料，校验失败返回 FieldErrors。
+func (s *merchantService) RegisterMerchant(ctx context.Context, req types.RegisterMerchantRequest) (*types.RegisterMerchantResponse, error) {
+	if s == nil || s.client == nil {
+		return nil, errors.New("client is nil")
+	}
+	if err := ValidateRegisterMerchant(req, time.Now()); err != nil {
+		return nil, err
+	}
+	if s.client.GetAccessToken() == "" {
+		return nil, errors.New("accessToken is empty")
+	}
+
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+
+	uri := s.client.BuildURIWithAuth(registerMerchantURI)
+
+	resp, err := s.client.Do(ctx, http.MethodPost, uri, body)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	raw, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		return nil, fmt.Errorf("wechat api http status %d: %s", resp.StatusCode, string(raw))
+	}
+
+	var out types.RegisterMerchantResponse
+	if err := json.Unmarshal(raw, &out); err != nil {
+		return nil, err
+	}
+	if out.ErrCode != 0 {
+		return &out, fmt.Errorf("wechat api error: errcode=%d errmsg=%s", out.ErrCode, out.ErrMsg)
+	}
+	return &out, nil
+}
+
+// GetMerchantOpenStatus 查询商户号进件与开通状态，可按 out_registration_id 查询单个申请或分页查询。
+func (s *merchantService) GetMerchantOpenStatus(ctx context.Context, req types.GetMerchantOpenStatusRequest) (*types.GetMerchantOpenStatusResponse, error) {
+	if s == nil || s.client == nil {
+		return nil, errors.New("client is nil")
+	}
+	if req.OutRegistrationID == "" && req.PageSize == 0 {
+		return nil, errors.New("out_registration_id or page_size is required")
+	}
+	if s.client.GetAccessToken() == "" {
+		return nil, errors.New("accessToken is empty")
+	}
+
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+
+	uri := s.client.BuildURIWithAuth(getMerchantOpenStatusURI)
+
+	resp, err := s.client.Do(ctx, http.MethodPost, uri, body)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	raw, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		return nil, fmt.Errorf("wechat api http status %d: %s", resp.StatusCode, string(raw))
+	}
+
+	var out types.GetMerchantOpenStatusResponse
+	if err := json.Unmarshal(raw, &out); err != nil {
+		return nil, err
+	}
+	if out.ErrCode != 0 {
+		return &out, fmt.Errorf("wechat api error: errcode=%d errmsg=%s", out.ErrCode, out.ErrMsg)
+	}
+	return &out, nil
+}
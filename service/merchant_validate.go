@@ -0,0 +1,301 @@
+package service
+
+import (
+	"net"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/wneverfade/wechatpay-b2b/model"
+	"github.com/wneverfade/wechatpay-b2b/types"
+)
+
+// 进件资料中的证件有效期格式。
+const (
+	periodDateLayout = "2006-01-02"
+	periodLongTerm   = "长期"
+)
+
+var (
+	mobilePattern      = regexp.MustCompile(`^1[3-9]\d{9}$`)
+	bankAddressPattern = regexp.MustCompile(`^\d{6}$`)
+
+	idCardWeights     = [17]int{7, 9, 10, 5, 8, 4, 2, 1, 6, 3, 7, 9, 10, 5, 8, 4, 2}
+	idCardCheckCodes  = "10X98765432"
+	creditCodeCharset = "0123456789ABCDEFGHJKLMNPQRTUWXY"
+	creditCodeWeights = [17]int{1, 3, 9, 27, 19, 26, 16, 17, 20, 29, 25, 13, 8, 24, 10, 30, 28}
+)
+
+// FieldError 表示单个字段的校验错误，Field 为 JSON 字段路径，如 id_card_info.id_card_number。
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// Error 返回 "字段路径: 错误信息"。
+func (e FieldError) Error() string {
+	return e.Field + ": " + e.Message
+}
+
+// FieldErrors 表示多个字段的校验错误。
+type FieldErrors []FieldError
+
+// Error 以分号拼接全部字段错误。
+func (e FieldErrors) Error() string {
+	msgs := make([]string, len(e))
+	for i, fe := range e {
+		msgs[i] = fe.Error()
+	}
+	return strings.Join(msgs, "; ")
+}
+
+func (e *FieldErrors) add(field, message string) {
+	*e = append(*e, FieldError{Field: field, Message: message})
+}
+
+func (e *FieldErrors) required(field, value string) bool {
+	if strings.TrimSpace(value) == "" {
+		e.add(field, "is required")
+		return false
+	}
+	return true
+}
+
+// 结算账户类型，兼容数字与字符串两种取值。
+var (
+	corporateAccountTypes = map[string]bool{"74": true, "BANK_ACCOUNT_TYPE_CORPORATE": true}
+	personalAccountTypes  = map[string]bool{"75": true, "BANK_ACCOUNT_TYPE_PERSONAL": true}
+)
+
+// 超级管理员类型：经营者/法人或经办人。
+var (
+	legalContactTypes = map[string]bool{"65": true, "LEGAL": true}
+	agentContactTypes = map[string]bool{"66": true, "SUPER": true}
+)
+
+// ValidateRegisterMerchant 在提交进件前校验资料，全部通过时返回 nil，否则返回 FieldErrors，可通过 errors.As 取出各字段错误。
+// 校验身份证号与统一社会信用代码校验位、手机号格式、证件有效期，以及按主体类型、证件类型、超级管理员类型决定的条件必填项。
+// now 用于判断证件是否已过期。
+func ValidateRegisterMerchant(req types.RegisterMerchantRequest, now time.Time) error {
+	var errs FieldErrors
+
+	if !req.OrganizationType.IsValid() {
+		errs.add("organization_type", "is invalid")
+	}
+	if !req.OpenType.IsValid() {
+		errs.add("open_type", "is invalid")
+	}
+	errs.required("merchant_shortname", req.MerchantShortname)
+	if errs.required("client_ip", req.ClientIP) && net.ParseIP(req.ClientIP) == nil {
+		errs.add("client_ip", "is not a valid ip address")
+	}
+
+	validateIdentity(&errs, req, now)
+	if req.OrganizationType.NeedsBusinessLicense() {
+		validateBusinessLicense(&errs, req.BusinessLicense)
+	}
+	validateAccountInfo(&errs, req.AccountInfo, req.OrganizationType)
+	validateContactInfo(&errs, req.ContactInfo, now)
+
+	if len(errs) == 0 {
+		return nil
+	}
+	return errs
+}
+
+// validateIdentity 按 id_doc_type_num 校验经营者/法人证件：身份证填写 id_card_info，其它证件填写 id_doc_info。
+func validateIdentity(errs *FieldErrors, req types.RegisterMerchantRequest, now time.Time) {
+	if !req.IDDocTypeNum.IsValid() {
+		errs.add("id_doc_type_num", "is invalid")
+		return
+	}
+
+	if req.IDDocTypeNum.IsIDCard() {
+		if req.IDDocInfo != nil {
+			errs.add("id_doc_info", "must be empty when id_doc_type_num is mainland id card")
+		}
+		info := req.IDCardInfo
+		if info == nil {
+			errs.add("id_card_info", "is required when id_doc_type_num is mainland id card")
+			return
+		}
+		errs.required("id_card_info.id_card_copy", info.IDCardCopy)
+		errs.required("id_card_info.id_card_national", info.IDCardNational)
+		errs.required("id_card_info.id_card_name", info.IDCardName)
+		if errs.required("id_card_info.id_card_number", info.IDCardNumber) && !validIDCardNumber(info.IDCardNumber) {
+			errs.add("id_card_info.id_card_number", "checksum mismatch")
+		}
+		validatePeriod(errs, "id_card_info.id_card_valid_time_begin", info.IDCardValidTimeBegin, "id_card_info.id_card_valid_time", info.IDCardValidTime, now)
+		return
+	}
+
+	if req.IDCardInfo != nil {
+		errs.add("id_card_info", "must be empty when id_doc_type_num is not mainland id card")
+	}
+	info := req.IDDocInfo
+	if info == nil {
+		errs.add("id_doc_info", "is required when id_doc_type_num is not mainland id card")
+		return
+	}
+	errs.required("id_doc_info.id_doc_name", info.IDDocName)
+	errs.required("id_doc_info.id_doc_number", info.IDDocNumber)
+	errs.required("id_doc_info.id_doc_copy", info.IDDocCopy)
+	validatePeriod(errs, "id_doc_info.doc_period_begin", info.DocPeriodBegin, "id_doc_info.doc_period_end", info.DocPeriodEnd, now)
+}
+
+// validateBusinessLicense 校验营业执照，18 位注册号按统一社会信用代码校验，15 位为旧版工商注册号。
+func validateBusinessLicense(errs *FieldErrors, lic types.BusinessLicense) {
+	errs.required("business_license.business_license_copy", lic.BusinessLicenseCopy)
+	errs.required("business_license.merchant_name", lic.MerchantName)
+	errs.required("business_license.legal_person", lic.LegalPerson)
+	const field = "business_license.business_license_number"
+	if !errs.required(field, lic.BusinessLicenseNumber) {
+		return
+	}
+	switch len(lic.BusinessLicenseNumber) {
+	case 18:
+		if !validCreditCode(lic.BusinessLicenseNumber) {
+			errs.add(field, "unified social credit code checksum mismatch")
+		}
+	case 15:
+		if !isDigits(lic.BusinessLicenseNumber) {
+			errs.add(field, "15-digit registration number must be numeric")
+		}
+	default:
+		errs.add(field, "must be 18-character unified social credit code or 15-digit registration number")
+	}
+}
+
+// validateAccountInfo 校验结算账户。非直连银行（account_bank 为"其他银行"）需填写 bank_branch_id 或 bank_name；
+// 企业与事业单位只能使用对公账户，小微商户与个人卖家只能使用对私账户。
+func validateAccountInfo(errs *FieldErrors, acc types.AccountInfo, org model.OrganizationType) {
+	if errs.required("account_info.bank_account_type", acc.BankAccountType) {
+		corporate, personal := corporateAccountTypes[acc.BankAccountType], personalAccountTypes[acc.BankAccountType]
+		switch {
+		case !corporate && !personal:
+			errs.add("account_info.bank_account_type", "is invalid")
+		case personal && (org == model.OrganizationEnterprise || org == model.OrganizationInstitution):
+			errs.add("account_info.bank_account_type", "must be corporate account for "+org.String())
+		case corporate && !org.NeedsBusinessLicense():
+			errs.add("account_info.bank_account_type", "must be personal account for "+org.String())
+		}
+	}
+	errs.required("account_info.account_name", acc.AccountName)
+	errs.required("account_info.account_number", acc.AccountNumber)
+	if errs.required("account_info.bank_address_code", acc.BankAddressCode) && !bankAddressPattern.MatchString(acc.BankAddressCode) {
+		errs.add("account_info.bank_address_code", "must be 6-digit region code")
+	}
+	if errs.required("account_info.account_bank", acc.AccountBank) && acc.AccountBank == "其他银行" &&
+		acc.BankBranchID == "" && acc.BankName == "" {
+		errs.add("account_info.bank_branch_id", "bank_branch_id or bank_name is required when account_bank is 其他银行")
+	}
+}
+
+// validateContactInfo 校验超级管理员，经办人需提供证件信息与业务办理授权函。
+func validateContactInfo(errs *FieldErrors, c types.ContactInfo, now time.Time) {
+	errs.required("contact_info.contact_name", c.ContactName)
+	if errs.required("contact_info.mobile_phone", c.MobilePhone) && !mobilePattern.MatchString(c.MobilePhone) {
+		errs.add("contact_info.mobile_phone", "is not a valid mainland mobile number")
+	}
+	if c.ContactEmail != "" && !strings.Contains(c.ContactEmail, "@") {
+		errs.add("contact_info.contact_email", "is not a valid email address")
+	}
+	if !errs.required("contact_info.contact_type", c.ContactType) {
+		return
+	}
+	if !legalContactTypes[c.ContactType] && !agentContactTypes[c.ContactType] {
+		errs.add("contact_info.contact_type", "is invalid")
+		return
+	}
+	if !agentContactTypes[c.ContactType] {
+		return
+	}
+
+	errs.required("contact_info.contact_id_doc_type", c.ContactIDDocType)
+	if errs.required("contact_info.contact_id_card_number", c.ContactIDCardNumber) &&
+		isMainlandIDCardDocType(c.ContactIDDocType) && !validIDCardNumber(c.ContactIDCardNumber) {
+		errs.add("contact_info.contact_id_card_number", "checksum mismatch")
+	}
+	errs.required("contact_info.contact_id_doc_copy", c.ContactIDDocCopy)
+	errs.required("contact_info.business_authorization_letter", c.BusinessAuthorizationLetter)
+	validatePeriod(errs, "contact_info.contact_id_doc_period_begin", c.ContactIDDocPeriodBegin, "contact_info.contact_id_doc_period_end", c.ContactIDDocPeriodEnd, now)
+}
+
+// validatePeriod 校验证件有效期：开始日期为 yyyy-MM-dd 且不晚于今天，结束日期为 yyyy-MM-dd 或"长期"，晚于开始日期且未过期。
+func validatePeriod(errs *FieldErrors, beginField, begin, endField, end string, now time.Time) {
+	beginOK := errs.required(beginField, begin)
+	endOK := errs.required(endField, end)
+	today := now.In(model.ShanghaiLocation).Format(periodDateLayout)
+
+	var beginDate time.Time
+	if beginOK {
+		var err error
+		if beginDate, err = time.Parse(periodDateLayout, begin); err != nil {
+			errs.add(beginField, "must be in yyyy-MM-dd format")
+			beginOK = false
+		} else if begin > today {
+			errs.add(beginField, "must not be in the future")
+		}
+	}
+	if !endOK || end == periodLongTerm {
+		return
+	}
+	endDate, err := time.Parse(periodDateLayout, end)
+	if err != nil {
+		errs.add(endField, "must be in yyyy-MM-dd format or 长期")
+		return
+	}
+	if beginOK && !endDate.After(beginDate) {
+		errs.add(endField, "must be after "+beginField)
+	}
+	if end < today {
+		errs.add(endField, "has expired")
+	}
+}
+
+// validIDCardNumber 校验 18 位居民身份证号的出生日期与校验位。
+func validIDCardNumber(s string) bool {
+	if len(s) != 18 || !isDigits(s[:17]) {
+		return false
+	}
+	if _, err := time.Parse("20060102", s[6:14]); err != nil {
+		return false
+	}
+	sum := 0
+	for i, w := range idCardWeights {
+		sum += int(s[i]-'0') * w
+	}
+	return strings.ToUpper(s[17:]) == string(idCardCheckCodes[sum%11])
+}
+
+// validCreditCode 校验 18 位统一社会信用代码（GB 32100-2015）的字符集与校验位。
+func validCreditCode(s string) bool {
+	if len(s) != 18 {
+		return false
+	}
+	s = strings.ToUpper(s)
+	sum := 0
+	for i, w := range creditCodeWeights {
+		v := strings.IndexByte(creditCodeCharset, s[i])
+		if v < 0 {
+			return false
+		}
+		sum += v * w
+	}
+	check := (31 - sum%31) % 31
+	return s[17] == creditCodeCharset[check]
+}
+
+// isMainlandIDCardDocType 判断超级管理员证件类型是否为中国大陆居民身份证。
+func isMainlandIDCardDocType(t string) bool {
+	return t == "IDENTIFICATION_TYPE_MAINLAND_IDCARD" || t == "IDENTIFICATION_TYPE_IDCARD" || t == "1"
+}
+
+func isDigits(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return s != ""
+}
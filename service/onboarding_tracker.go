@@ -0,0 +1,423 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/wneverfade/wechatpay-b2b/model"
+	"github.com/wneverfade/wechatpay-b2b/types"
+)
+
+const (
+	defaultOnboardingPageSize = 20
+	defaultOnboardingInterval = 5 * time.Minute
+	// firstMerchantOpenStatusPage GetMerchantOpenStatus 起始页码。
+	firstMerchantOpenStatusPage = 1
+)
+
+// OnboardingEventType 表示进件状态跟踪事件类型。
+type OnboardingEventType string
+
+const (
+	// OnboardingEventInReview 资料校验或审核中
+	OnboardingEventInReview OnboardingEventType = "IN_REVIEW"
+	// OnboardingEventNeedsRemittance 待账户验证，需按 Remittance 汇款
+	OnboardingEventNeedsRemittance OnboardingEventType = "NEEDS_REMITTANCE"
+	// OnboardingEventNeedsSignature 待签约，需打开 SignURL
+	OnboardingEventNeedsSignature OnboardingEventType = "NEEDS_SIGNATURE"
+	// OnboardingEventNeedsLegalValidation 需法人打开 LegalValidationURL 完成验证
+	OnboardingEventNeedsLegalValidation OnboardingEventType = "NEEDS_LEGAL_VALIDATION"
+	// OnboardingEventRejected 已驳回，驳回字段见 Rejections
+	OnboardingEventRejected OnboardingEventType = "REJECTED"
+	// OnboardingEventCompleted 进件完成，已生成 SubMchid
+	OnboardingEventCompleted OnboardingEventType = "COMPLETED"
+	// OnboardingEventFrozen 已冻结
+	OnboardingEventFrozen OnboardingEventType = "FROZEN"
+	// OnboardingEventCanceled 已作废
+	OnboardingEventCanceled OnboardingEventType = "CANCELED"
+	// OnboardingEventError 查询失败或状态未知
+	OnboardingEventError OnboardingEventType = "ERROR"
+)
+
+// OnboardingRejection 表示一条驳回原因。
+type OnboardingRejection struct {
+	ParamName string `json:"param_name"`
+	// Field 对应 RegisterMerchantRequest 的字段路径，如 id_card_info.id_card_number，无法对应时为空。
+	Field  string `json:"field,omitempty"`
+	Reason string `json:"reason"`
+}
+
+// OnboardingEvent 表示一次进件状态变化。
+type OnboardingEvent struct {
+	Type               OnboardingEventType
+	OutRegistrationID  string
+	Previous           model.ApplymentState // 上次记录的申请单状态，首次查询时为空
+	State              model.ApplymentState
+	SignURL            string                           // NeedsSignature
+	LegalValidationURL string                           // NeedsLegalValidation
+	Remittance         *types.MerchantAccountValidation // NeedsRemittance
+	Rejections         []OnboardingRejection            // Rejected
+	SubMchid           string                           // Completed
+	Status             *types.SubMerchantRegistrationStatus
+	Err                error
+}
+
+// OnboardingRecord 记录进件申请单最近一次的状态。
+type OnboardingRecord struct {
+	OutRegistrationID string               `json:"out_registration_id"`
+	State             model.ApplymentState `json:"state,omitempty"`
+	SignState         model.SignState      `json:"sign_state,omitempty"`
+	SubMchid          string               `json:"sub_mchid,omitempty"`
+	// Fingerprint 状态、签约链接、汇款信息与驳回原因的摘要，变化时才会产生事件。
+	Fingerprint string    `json:"fingerprint,omitempty"`
+	Finished    bool      `json:"finished"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// OnboardingStore 持久化进件跟踪记录。
+type OnboardingStore interface {
+	// Load 读取跟踪记录，不存在时返回 nil, nil。
+	Load(ctx context.Context, outRegistrationID string) (*OnboardingRecord, error)
+	// Save 新增或更新跟踪记录。
+	Save(ctx context.Context, rec OnboardingRecord) error
+	// Pending 返回所有未结束的跟踪记录。
+	Pending(ctx context.Context) ([]OnboardingRecord, error)
+}
+
+// OnboardingTrackerOptions 进件状态跟踪参数，零值使用默认配置。
+type OnboardingTrackerOptions struct {
+	PageSize uint32                // GetMerchantOpenStatus 每页条数，默认 20
+	Interval time.Duration         // Run 的查询间隔，默认 5 分钟
+	OnEvent  func(OnboardingEvent) // 每个事件回调
+	OnError  func(error)           // Run 中读写跟踪记录失败时回调
+	Now      func() time.Time      // 当前时间，默认 time.Now
+}
+
+// OnboardingTracker 跟踪商户号进件申请单，经审核、汇款验证、签约与法人验证直至完成或作废。
+// 仅在状态、签约链接、汇款信息或驳回原因变化时产生事件。
+// GetMerchantOpenStatus 返回的状态记录没有可排序字段，以全部分页中的最后一条作为当前状态，即假定记录按从旧到新返回。
+type OnboardingTracker struct {
+	merchant MerchantService
+	store    OnboardingStore
+	opts     OnboardingTrackerOptions
+}
+
+// NewOnboardingTracker 创建进件状态跟踪器。
+func NewOnboardingTracker(merchant MerchantService, store OnboardingStore, opts OnboardingTrackerOptions) *OnboardingTracker {
+	if opts.PageSize == 0 {
+		opts.PageSize = defaultOnboardingPageSize
+	}
+	if opts.Interval <= 0 {
+		opts.Interval = defaultOnboardingInterval
+	}
+	if opts.Now == nil {
+		opts.Now = time.Now
+	}
+	return &OnboardingTracker{merchant: merchant, store: store, opts: opts}
+}
+
+// Track 登记需要跟踪的进件申请单，已登记时不做修改。
+func (t *OnboardingTracker) Track(ctx context.Context, outRegistrationID string) error {
+	if t.store == nil {
+		return errors.New("onboarding store is nil")
+	}
+	if outRegistrationID == "" {
+		return errors.New("out_registration_id is required")
+	}
+	rec, err := t.store.Load(ctx, outRegistrationID)
+	if err != nil || rec != nil {
+		return err
+	}
+	now := t.opts.Now()
+	return t.store.Save(ctx, OnboardingRecord{OutRegistrationID: outRegistrationID, CreatedAt: now, UpdatedAt: now})
+}
+
+// Run 按 Interval 周期性查询所有未结束的申请单，直到 ctx 结束。
+func (t *OnboardingTracker) Run(ctx context.Context) error {
+	ticker := time.NewTicker(t.opts.Interval)
+	defer ticker.Stop()
+	for {
+		if _, err := t.RunOnce(ctx); err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			if t.opts.OnError != nil {
+				t.opts.OnError(err)
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunOnce 查询一次所有未结束的申请单。
+func (t *OnboardingTracker) RunOnce(ctx context.Context) ([]OnboardingEvent, error) {
+	if t.store == nil {
+		return nil, errors.New("onboarding store is nil")
+	}
+	recs, err := t.store.Pending(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var events []OnboardingEvent
+	for _, rec := range recs {
+		if err := ctx.Err(); err != nil {
+			return events, err
+		}
+		evs, err := t.Check(ctx, rec.OutRegistrationID)
+		events = append(events, evs...)
+		if err != nil {
+			return events, err
+		}
+	}
+	return events, nil
+}
+
+// Check 查询单个申请单的最新状态，与上次记录对比后返回事件并更新记录。
+// 未登记的申请单会自动登记。查询失败产生 OnboardingEventError 事件，不返回错误；读写跟踪记录失败时返回错误。
+func (t *OnboardingTracker) Check(ctx context.Context, outRegistrationID string) ([]OnboardingEvent, error) {
+	if t.merchant == nil || t.store == nil {
+		return nil, errors.New("onboarding tracker is not initialized")
+	}
+	if outRegistrationID == "" {
+		return nil, errors.New("out_registration_id is required")
+	}
+	rec, err := t.store.Load(ctx, outRegistrationID)
+	if err != nil {
+		return nil, err
+	}
+	now := t.opts.Now()
+	if rec == nil {
+		rec = &OnboardingRecord{OutRegistrationID: outRegistrationID, CreatedAt: now}
+	}
+
+	status, err := t.latestStatus(ctx, outRegistrationID)
+	if err != nil {
+		return t.emit([]OnboardingEvent{{Type: OnboardingEventError, OutRegistrationID: outRegistrationID, Previous: rec.State, State: rec.State, Err: err}}), nil
+	}
+	if status == nil {
+		// 申请单尚未生成审核状态。
+		return nil, nil
+	}
+
+	fp := onboardingFingerprint(status)
+	if fp == rec.Fingerprint {
+		return nil, nil
+	}
+	events := onboardingEvents(outRegistrationID, rec.State, status)
+
+	rec.State = status.ApplymentState
+	rec.SignState = status.SignState
+	if status.SubMchid != "" {
+		rec.SubMchid = status.SubMchid
+	}
+	rec.Fingerprint = fp
+	rec.Finished = status.ApplymentState.IsTerminal()
+	rec.UpdatedAt = now
+	if err := t.store.Save(ctx, *rec); err != nil {
+		return nil, err
+	}
+	return t.emit(events), nil
+}
+
+func (t *OnboardingTracker) emit(events []OnboardingEvent) []OnboardingEvent {
+	if t.opts.OnEvent != nil {
+		for _, e := range events {
+			t.opts.OnEvent(e)
+		}
+	}
+	return events
+}
+
+// latestStatus 分页查询申请单的全部状态记录，返回最后一条带审核状态的记录，没有时返回 nil。
+// 状态记录不含时间或序号等可排序字段，这里假定接口按从旧到新的顺序返回（与页码递增方向一致），
+// 若接口实际按从新到旧返回，跟踪到的将是最早的状态。
+func (t *OnboardingTracker) latestStatus(ctx context.Context, outRegistrationID string) (*types.SubMerchantRegistrationStatus, error) {
+	var latest *types.SubMerchantRegistrationStatus
+	seen := 0
+	for page := uint32(firstMerchantOpenStatusPage); ; page++ {
+		resp, err := t.merchant.GetMerchantOpenStatus(ctx, types.GetMerchantOpenStatusRequest{
+			OutRegistrationID: outRegistrationID,
+			PageIndex:         page,
+			PageSize:          t.opts.PageSize,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("get merchant open status page %d: %w", page, err)
+		}
+		for _, item := range resp.List {
+			if item.InnerResp != nil && item.InnerResp.SubMerchantRegistrationStatus != nil {
+				latest = item.InnerResp.SubMerchantRegistrationStatus
+			}
+		}
+		seen += len(resp.List)
+		if len(resp.List) < int(t.opts.PageSize) || seen >= int(resp.Total) {
+			return latest, nil
+		}
+	}
+}
+
+// onboardingEvents 根据最新状态生成事件，法人验证链接与申请单状态事件可同时出现。
+func onboardingEvents(outRegistrationID string, previous model.ApplymentState, status *types.SubMerchantRegistrationStatus) []OnboardingEvent {
+	e := OnboardingEvent{
+		OutRegistrationID: outRegistrationID,
+		Previous:          previous,
+		State:             status.ApplymentState,
+		Status:            status,
+	}
+	switch status.ApplymentState {
+	case model.ApplymentChecking, model.ApplymentAuditing:
+		e.Type = OnboardingEventInReview
+	case model.ApplymentAccountNeedVerify:
+		e.Type = OnboardingEventNeedsRemittance
+		e.Remittance = status.AccountValidation
+	case model.ApplymentNeedSign:
+		e.Type = OnboardingEventNeedsSignature
+		e.SignURL = status.SignURL
+	case model.ApplymentRejected:
+		e.Type = OnboardingEventRejected
+		e.Rejections = OnboardingRejections(status.AuditDetail)
+	case model.ApplymentFinish:
+		e.Type = OnboardingEventCompleted
+		e.SubMchid = status.SubMchid
+	case model.ApplymentFrozen:
+		e.Type = OnboardingEventFrozen
+	case model.ApplymentCanceled:
+		e.Type = OnboardingEventCanceled
+	default:
+		e.Type = OnboardingEventError
+		e.Err = fmt.Errorf("%w: applyment_state %q", model.ErrUnknownStatus, status.ApplymentState)
+	}
+	events := []OnboardingEvent{e}
+	if status.LegalValidationURL != "" && !status.ApplymentState.IsTerminal() {
+		legal := e
+		legal.Type, legal.Err = OnboardingEventNeedsLegalValidation, nil
+		legal.LegalValidationURL = status.LegalValidationURL
+		events = append(events, legal)
+	}
+	return events
+}
+
+// onboardingFingerprint 生成用于判断状态是否变化的摘要。
+func onboardingFingerprint(s *types.SubMerchantRegistrationStatus) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s|%s|%s|%s|%s", s.ApplymentState, s.SignState, s.SignURL, s.LegalValidationURL, s.SubMchid)
+	if v := s.AccountValidation; v != nil {
+		fmt.Fprintf(&b, "|%s|%s|%d|%s", v.AccountNo, v.DestinationAccountNumber, v.PayAmount, v.Deadline)
+	}
+	for _, d := range s.AuditDetail {
+		fmt.Fprintf(&b, "|%s=%s", d.ParamName, d.RejectReason)
+	}
+	return b.String()
+}
+
+// OnboardingRejections 将驳回原因的 param_name 映射到 RegisterMerchantRequest 的字段路径。
+func OnboardingRejections(details []types.MerchantAuditDetail) []OnboardingRejection {
+	out := make([]OnboardingRejection, 0, len(details))
+	for _, d := range details {
+		field, _ := RegisterMerchantFieldPath(d.ParamName)
+		out = append(out, OnboardingRejection{ParamName: d.ParamName, Field: field, Reason: d.RejectReason})
+	}
+	return out
+}
+
+// RegisterMerchantFieldPath 将驳回原因中的 param_name 映射到 RegisterMerchantRequest 的 JSON 字段路径。
+// param_name 可以是完整路径（id_card_info.id_card_number）或唯一的末级字段名（id_card_number），无法唯一对应时返回 false。
+func RegisterMerchantFieldPath(paramName string) (string, bool) {
+	name := strings.TrimSpace(paramName)
+	if name == "" {
+		return "", false
+	}
+	path := registerMerchantFieldPaths()[name]
+	return path, path != ""
+}
+
+// registerMerchantFieldPaths 通过 json 标签收集 RegisterMerchantRequest 的字段路径，
+// 键为完整路径与末级字段名，末级字段名重复时对应空字符串。
+var registerMerchantFieldPaths = sync.OnceValue(func() map[string]string {
+	paths := make(map[string]string)
+	var walk func(t reflect.Type, prefix string)
+	walk = func(t reflect.Type, prefix string) {
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+			if name == "" || name == "-" {
+				continue
+			}
+			path := name
+			if prefix != "" {
+				path = prefix + "." + name
+			}
+			paths[path] = path
+			if prefix != "" {
+				if existing, ok := paths[name]; ok && existing != path {
+					paths[name] = ""
+				} else if !ok {
+					paths[name] = path
+				}
+			}
+			ft := f.Type
+			if ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				walk(ft, path)
+			}
+		}
+	}
+	walk(reflect.TypeOf(types.RegisterMerchantRequest{}), "")
+	return paths
+})
+
+// MemoryOnboardingStore 是 OnboardingStore 的内存实现，进程重启后数据会丢失，适用于测试或单机场景。
+type MemoryOnboardingStore struct {
+	mu      sync.Mutex
+	records map[string]OnboardingRecord
+}
+
+// NewMemoryOnboardingStore 创建内存进件跟踪记录存储。
+func NewMemoryOnboardingStore() *MemoryOnboardingStore {
+	return &MemoryOnboardingStore{records: make(map[string]OnboardingRecord)}
+}
+
+// Load 读取跟踪记录。
+func (m *MemoryOnboardingStore) Load(ctx context.Context, outRegistrationID string) (*OnboardingRecord, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	rec, ok := m.records[outRegistrationID]
+	if !ok {
+		return nil, nil
+	}
+	return &rec, nil
+}
+
+// Save 新增或更新跟踪记录。
+func (m *MemoryOnboardingStore) Save(ctx context.Context, rec OnboardingRecord) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.records[rec.OutRegistrationID] = rec
+	return nil
+}
+
+// Pending 返回所有未结束的跟踪记录，按 CreatedAt 升序。
+func (m *MemoryOnboardingStore) Pending(ctx context.Context) ([]OnboardingRecord, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var out []OnboardingRecord
+	for _, rec := range m.records {
+		if !rec.Finished {
+			out = append(out, rec)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].CreatedAt.Before(out[j].CreatedAt) })
+	return out, nil
+}
@@ -9,6 +9,7 @@ import (
 	"net/http"
 
 	"github.com/wneverfade/wechatpay-b2b/client"
+	"github.com/wneverfade/wechatpay-b2b/model"
 	"github.com/wneverfade/wechatpay-b2b/types"
 )
 
@@ -22,10 +23,19 @@ type OrderService interface {
 	CreateRefund(ctx context.Context, req types.RefundRequest, appKey string) (*types.RefundResponse, error)
 	// GetRefund 查询退款。
 	GetRefund(ctx context.Context, req types.GetRefundRequest, appKey string) (*types.GetRefundResponse, error)
+	// BatchGetOrder 以有限并发批量查询订单，结果通过 channel 返回；提前停止读取时需取消 ctx。
+	BatchGetOrder(ctx context.Context, reqs []types.GetOrderRequest, appKeys AppKeyResolver, opts BatchOptions) <-chan BatchOrderResult
+	// WaitForPayment 轮询查询订单，直到支付成功、关闭或 ctx 结束。
+	WaitForPayment(ctx context.Context, mchid, outTradeNo string, appKey string, opts WaitOptions) (*types.GetOrderResponse, error)
+	// WatchPayment 轮询查询订单并通过 channel 推送每次查询结果。
+	WatchPayment(ctx context.Context, mchid, outTradeNo string, appKey string, opts WaitOptions) <-chan PaymentEvent
+	// WaitForRefund 轮询查询退款，直到退款成功、失败或 ctx 结束。
+	WaitForRefund(ctx context.Context, req types.GetRefundRequest, appKey string, opts WaitOptions) (*types.GetRefundResponse, error)
 	// BuildPaymentParams 生成单订单支付参数，用于小程序 wx.requestCommonPayment。
-	// BuildPaymentParams(ctx context.Context, req types.Order, sessionKey string, appKey string) (*types.CommonPaymentParams, error)
+	BuildPaymentParams(ctx context.Context, req types.Order, sessionKey string, appKey string) (*types.CommonPaymentParams, error)
 	// BuildCombinedPaymentParams 生成合单支付参数，用于小程序 wx.requestCommonPayment。
-	BuildCombinedPaymentParams(ctx context.Context, req types.CombinedPaymentSignData, sessionKey string) (*types.CommonPaymentParams, error)
+	// appKeys 按子单 mchid 提供 appKey，appKey 只参与签名，不会写入 signData。
+	BuildCombinedPaymentParams(ctx context.Context, req types.CombinedPaymentSignData, sessionKey string, appKeys AppKeyResolver) (*types.CommonPaymentParams, error)
 }
 
 type orderService struct {
@@ -247,6 +257,9 @@ func (s *orderService) BuildPaymentParams(ctx context.Context, req types.Order,
 	if sessionKey == "" {
 		return nil, errors.New("sessionKey is empty")
 	}
+	if err := validateOrder(req, s.client.GetEnv()); err != nil {
+		return nil, err
+	}
 
 	body, err := json.Marshal(req)
 	if err != nil {
@@ -261,8 +274,57 @@ func (s *orderService) BuildPaymentParams(ctx context.Context, req types.Order,
 	}, nil
 }
 
+// validateOrder 校验单订单支付参数。
+func validateOrder(req types.Order, env model.Env) error {
+	if req.Mchid == "" {
+		return errors.New("mchid is required")
+	}
+	if req.OutTradeNo == "" {
+		return errors.New("out_trade_no is required")
+	}
+	if req.Description == "" {
+		return errors.New("description is required")
+	}
+	if model.Env(req.Env) != env {
+		return fmt.Errorf("env %d does not match client env %d", req.Env, env)
+	}
+
+	amount := req.Amount
+	if amount.OrderAmount <= 0 {
+		return errors.New("order_amount must be positive")
+	}
+	if amount.ProductAmount < 0 || amount.Freight < 0 || amount.Discount < 0 || amount.OtherFee < 0 {
+		return errors.New("amount fields must not be negative")
+	}
+	if amount.ProductAmount != 0 || amount.Freight != 0 || amount.Discount != 0 || amount.OtherFee != 0 {
+		total, err := model.SumMoney(amount.ProductAmount, amount.Freight, amount.OtherFee)
+		if err != nil {
+			return err
+		}
+		if total, err = total.Sub(amount.Discount); err != nil {
+			return err
+		}
+		if total != amount.OrderAmount {
+			return fmt.Errorf("order_amount %d does not equal product_amount + freight + other_fee - discount (%d)", amount.OrderAmount, total)
+		}
+	}
+
+	if p := req.ProductInfo; p != nil {
+		if p.Quantity <= 0 {
+			return errors.New("product_info.quantity must be positive")
+		}
+		if p.OrgPrice < 0 || p.SalePrice < 0 {
+			return errors.New("product_info price must not be negative")
+		}
+		if p.OrgPrice > 0 && p.SalePrice > p.OrgPrice {
+			return errors.New("product_info.sale_price must not exceed org_price")
+		}
+	}
+	return nil
+}
+
 // BuildCombinedPaymentParams 生成合单支付参数，用于小程序 wx.requestCommonPayment。
-func (s *orderService) BuildCombinedPaymentParams(ctx context.Context, req types.CombinedPaymentSignData, sessionKey string) (*types.CommonPaymentParams, error) {
+func (s *orderService) BuildCombinedPaymentParams(ctx context.Context, req types.CombinedPaymentSignData, sessionKey string, appKeys AppKeyResolver) (*types.CommonPaymentParams, error) {
 	if s.client == nil {
 		return nil, errors.New("client is nil")
 	}
@@ -270,6 +332,9 @@ func (s *orderService) BuildCombinedPaymentParams(ctx context.Context, req types
 	if sessionKey == "" {
 		return nil, errors.New("sessionKey is empty")
 	}
+	if appKeys == nil {
+		return nil, errors.New("appKeys is nil")
+	}
 	if len(req.CombinedOrderList) == 0 {
 		return nil, errors.New("combined_order_list is required")
 	}
@@ -286,10 +351,17 @@ func (s *orderService) BuildCombinedPaymentParams(ctx context.Context, req types
 
 	paySigItems := make([]paySigItem, 0, len(req.CombinedOrderList))
 	for _, order := range req.CombinedOrderList {
-		if order.Mchid == "" {
+		if order == nil || order.Mchid == "" {
 			return nil, errors.New("mchid is required")
 		}
-		paySigPer := s.client.GetPaySig(requestCommonPaymentURI, body, order.AppKey)
+		appKey, err := appKeys.AppKey(ctx, order.Mchid)
+		if err != nil {
+			return nil, err
+		}
+		if appKey == "" {
+			return nil, errors.New("appKey is empty")
+		}
+		paySigPer := s.client.GetPaySig(requestCommonPaymentURI, body, appKey)
 
 		paySigItems = append(paySigItems, paySigItem{
 			Mchid:  order.Mchid,
@@ -0,0 +1,141 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/wneverfade/wechatpay-b2b/types"
+)
+
+const defaultBatchConcurrency = 8
+
+// BatchOptions 批量查询参数，零值使用默认配置。
+type BatchOptions struct {
+	Concurrency   int     // 最大并发数，默认 8
+	RatePerSecond float64 // 每秒最多发起的请求数，0 表示不限速
+	Ordered       bool    // 为 true 时按输入顺序输出结果，否则按完成顺序输出
+}
+
+// BatchOrderResult 表示批量查询中单个订单的结果。
+type BatchOrderResult struct {
+	Index   int                     // 在输入列表中的序号
+	Request types.GetOrderRequest   // 查询参数
+	Order   *types.GetOrderResponse // 查询结果
+	Err     error                   // 查询错误
+}
+
+// BatchGetOrder 以有限并发批量查询订单，结果通过 channel 返回，全部完成后关闭 channel。
+// ctx 结束后尚未发起的查询以 ctx.Err() 作为结果。调用方需读取到 channel 关闭；提前停止读取时必须取消 ctx，
+// 否则后台 goroutine 会一直阻塞在发送结果上。
+func (s *orderService) BatchGetOrder(ctx context.Context, reqs []types.GetOrderRequest, appKeys AppKeyResolver, opts BatchOptions) <-chan BatchOrderResult {
+	if opts.Concurrency <= 0 {
+		opts.Concurrency = defaultBatchConcurrency
+	}
+	out := make(chan BatchOrderResult, opts.Concurrency)
+	results := make(chan BatchOrderResult, opts.Concurrency)
+
+	var limiter <-chan time.Time
+	var ticker *time.Ticker
+	if opts.RatePerSecond > 0 {
+		if interval := time.Duration(float64(time.Second) / opts.RatePerSecond); interval > 0 {
+			ticker = time.NewTicker(interval)
+			limiter = ticker.C
+		}
+	}
+
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+
+	// 分发任务，ctx 结束后为剩余请求直接生成错误结果。
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		defer close(jobs)
+		for i := range reqs {
+			select {
+			case jobs <- i:
+			case <-ctx.Done():
+				for j := i; j < len(reqs); j++ {
+					results <- BatchOrderResult{Index: j, Request: reqs[j], Err: ctx.Err()}
+				}
+				return
+			}
+		}
+	}()
+
+	for w := 0; w < opts.Concurrency; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				results <- s.batchGetOrder(ctx, i, reqs[i], appKeys, limiter)
+			}
+		}()
+	}
+
+	go func() {
+		wg.Wait()
+		if ticker != nil {
+			ticker.Stop()
+		}
+		close(results)
+	}()
+
+	go func() {
+		defer close(out)
+		emit := func(r BatchOrderResult) {
+			select {
+			case out <- r:
+			case <-ctx.Done():
+			}
+		}
+		if !opts.Ordered {
+			for r := range results {
+				emit(r)
+			}
+			return
+		}
+		pending := make(map[int]BatchOrderResult)
+		next := 0
+		for r := range results {
+			pending[r.Index] = r
+			for {
+				p, ok := pending[next]
+				if !ok {
+					break
+				}
+				delete(pending, next)
+				emit(p)
+				next++
+			}
+		}
+	}()
+
+	return out
+}
+
+// batchGetOrder 在限速后查询单个订单。
+func (s *orderService) batchGetOrder(ctx context.Context, index int, req types.GetOrderRequest, appKeys AppKeyResolver, limiter <-chan time.Time) BatchOrderResult {
+	res := BatchOrderResult{Index: index, Request: req}
+	if appKeys == nil {
+		res.Err = errors.New("appKeys is nil")
+		return res
+	}
+	if limiter != nil {
+		select {
+		case <-limiter:
+		case <-ctx.Done():
+			res.Err = ctx.Err()
+			return res
+		}
+	}
+	appKey, err := appKeys.AppKey(ctx, req.Mchid)
+	if err != nil {
+		res.Err = err
+		return res
+	}
+	res.Order, res.Err = s.GetOrder(ctx, req, appKey)
+	return res
+}
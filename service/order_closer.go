@@ -0,0 +1,267 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/wneverfade/wechatpay-b2b/model"
+	"github.com/wneverfade/wechatpay-b2b/types"
+)
+
+const (
+	defaultCloseInterval    = 30 * time.Second
+	defaultCloseMaxAttempts = 5
+)
+
+// PendingClose 表示等待超时关闭的订单。
+type PendingClose struct {
+	Mchid      string    `json:"mchid"`
+	OutTradeNo string    `json:"out_trade_no"`
+	Deadline   time.Time `json:"deadline"` // 超过该时间仍未支付则关闭
+	Attempts   int       `json:"attempts"` // 已尝试处理次数
+}
+
+// CloseStore 持久化待关闭订单，保证进程重启后不会丢失。
+type CloseStore interface {
+	// Save 新增或更新待关闭订单（以 mchid + out_trade_no 为键）。
+	Save(ctx context.Context, item PendingClose) error
+	// Delete 删除待关闭订单，不存在时返回 nil。
+	Delete(ctx context.Context, mchid, outTradeNo string) error
+	// Due 返回 Deadline 不晚于 now 的待关闭订单。
+	Due(ctx context.Context, now time.Time) ([]PendingClose, error)
+}
+
+// CloseOutcome 表示一次超时关单处理的结果。
+type CloseOutcome string
+
+const (
+	// CloseOutcomeClosed 已调用 CloseOrder 关闭订单
+	CloseOutcomeClosed CloseOutcome = "CLOSED"
+	// CloseOutcomePaid 订单已支付（含关单过程中完成支付），不关闭
+	CloseOutcomePaid CloseOutcome = "PAID"
+	// CloseOutcomeAlreadyClosed 订单已处于关闭状态
+	CloseOutcomeAlreadyClosed CloseOutcome = "ALREADY_CLOSED"
+	// CloseOutcomeRetry 处理失败，等待下次重试
+	CloseOutcomeRetry CloseOutcome = "RETRY"
+	// CloseOutcomeGaveUp 超过最大重试次数，已放弃
+	CloseOutcomeGaveUp CloseOutcome = "GAVE_UP"
+)
+
+// CloseResult 表示单个订单的超时关单处理结果。
+type CloseResult struct {
+	Item    PendingClose
+	Outcome CloseOutcome
+	Order   *types.GetOrderResponse // 最后一次查询到的订单
+	Err     error
+}
+
+// OrderCloserOptions 超时关单参数，零值使用默认配置。
+type OrderCloserOptions struct {
+	Interval    time.Duration     // Run 的扫描间隔，默认 30s
+	MaxAttempts int               // 单个订单最大处理次数，默认 5
+	OnResult    func(CloseResult) // 每个订单处理完成后回调
+	OnError     func(error)       // Run 中读写存储失败时回调
+	Now         func() time.Time  // 当前时间，默认 time.Now
+}
+
+// OrderCloser 关闭超过支付时限仍未支付的订单。
+// 关单前先查询订单，已支付的订单不会被关闭；关单失败时会再次查询，识别关单过程中完成支付的情况。
+type OrderCloser struct {
+	orders  OrderService
+	store   CloseStore
+	appKeys AppKeyResolver
+	opts    OrderCloserOptions
+}
+
+// NewOrderCloser 创建超时关单调度器。
+func NewOrderCloser(orders OrderService, store CloseStore, appKeys AppKeyResolver, opts OrderCloserOptions) *OrderCloser {
+	if opts.Interval <= 0 {
+		opts.Interval = defaultCloseInterval
+	}
+	if opts.MaxAttempts <= 0 {
+		opts.MaxAttempts = defaultCloseMaxAttempts
+	}
+	if opts.Now == nil {
+		opts.Now = time.Now
+	}
+	return &OrderCloser{orders: orders, store: store, appKeys: appKeys, opts: opts}
+}
+
+// Track 登记订单的支付截止时间。
+func (c *OrderCloser) Track(ctx context.Context, mchid, outTradeNo string, deadline time.Time) error {
+	if mchid == "" {
+		return errors.New("mchid is required")
+	}
+	if outTradeNo == "" {
+		return errors.New("out_trade_no is required")
+	}
+	return c.store.Save(ctx, PendingClose{Mchid: mchid, OutTradeNo: outTradeNo, Deadline: deadline})
+}
+
+// Untrack 取消跟踪订单，如收到支付成功通知时调用。
+func (c *OrderCloser) Untrack(ctx context.Context, mchid, outTradeNo string) error {
+	return c.store.Delete(ctx, mchid, outTradeNo)
+}
+
+// Run 按 Interval 周期性处理到期订单，直到 ctx 结束。
+func (c *OrderCloser) Run(ctx context.Context) error {
+	ticker := time.NewTicker(c.opts.Interval)
+	defer ticker.Stop()
+	for {
+		if _, err := c.RunOnce(ctx); err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			if c.opts.OnError != nil {
+				c.opts.OnError(err)
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunOnce 处理一次所有到期订单。
+func (c *OrderCloser) RunOnce(ctx context.Context) ([]CloseResult, error) {
+	if c.orders == nil || c.store == nil || c.appKeys == nil {
+		return nil, errors.New("order closer is not initialized")
+	}
+	items, err := c.store.Due(ctx, c.opts.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]CloseResult, 0, len(items))
+	for _, item := range items {
+		if err := ctx.Err(); err != nil {
+			return results, err
+		}
+		res := c.process(ctx, item)
+		if err := c.finish(ctx, &res); err != nil {
+			return results, err
+		}
+		if c.opts.OnResult != nil {
+			c.opts.OnResult(res)
+		}
+		results = append(results, res)
+	}
+	return results, nil
+}
+
+// process 查询并在必要时关闭单个订单。
+func (c *OrderCloser) process(ctx context.Context, item PendingClose) CloseResult {
+	item.Attempts++
+	res := CloseResult{Item: item, Outcome: CloseOutcomeRetry}
+
+	appKey, err := c.appKeys.AppKey(ctx, item.Mchid)
+	if err != nil {
+		res.Err = err
+		return res
+	}
+	req := types.GetOrderRequest{Mchid: item.Mchid, OutTradeNo: item.OutTradeNo}
+
+	order, err := c.orders.GetOrder(ctx, req, appKey)
+	if err != nil {
+		res.Err = err
+		return res
+	}
+	res.Order = order
+	if outcome, ok := closeOutcomeOf(order.PayStatus); ok {
+		res.Outcome = outcome
+		return res
+	}
+	if !order.PayStatus.CanClose() {
+		res.Err = fmt.Errorf("%w: pay_status %q", model.ErrUnknownStatus, order.PayStatus)
+		return res
+	}
+
+	_, closeErr := c.orders.CloseOrder(ctx, types.CloseOrderRequest{Mchid: item.Mchid, OutTradeNo: item.OutTradeNo}, appKey)
+	if closeErr == nil {
+		res.Outcome = CloseOutcomeClosed
+		return res
+	}
+
+	// 关单失败时再次查询，用户可能恰好在关单前完成了支付。
+	order, err = c.orders.GetOrder(ctx, req, appKey)
+	if err != nil {
+		res.Err = errors.Join(closeErr, err)
+		return res
+	}
+	res.Order = order
+	if outcome, ok := closeOutcomeOf(order.PayStatus); ok {
+		res.Outcome = outcome
+		return res
+	}
+	res.Err = closeErr
+	return res
+}
+
+// finish 根据处理结果更新存储。
+func (c *OrderCloser) finish(ctx context.Context, res *CloseResult) error {
+	if res.Outcome == CloseOutcomeRetry && res.Item.Attempts >= c.opts.MaxAttempts {
+		res.Outcome = CloseOutcomeGaveUp
+	}
+	if res.Outcome == CloseOutcomeRetry {
+		return c.store.Save(ctx, res.Item)
+	}
+	return c.store.Delete(ctx, res.Item.Mchid, res.Item.OutTradeNo)
+}
+
+// closeOutcomeOf 返回无需关单的订单状态对应的处理结果。
+func closeOutcomeOf(status model.PayStatus) (CloseOutcome, bool) {
+	switch {
+	case status.IsPaid():
+		return CloseOutcomePaid, true
+	case status == model.PayStatusClosed:
+		return CloseOutcomeAlreadyClosed, true
+	}
+	return "", false
+}
+
+// MemoryCloseStore 是 CloseStore 的内存实现，进程重启后数据会丢失，适用于测试或单机场景。
+type MemoryCloseStore struct {
+	mu    sync.Mutex
+	items map[string]PendingClose
+}
+
+// NewMemoryCloseStore 创建内存待关单存储。
+func NewMemoryCloseStore() *MemoryCloseStore {
+	return &MemoryCloseStore{items: make(map[string]PendingClose)}
+}
+
+// Save 新增或更新待关闭订单。
+func (m *MemoryCloseStore) Save(ctx context.Context, item PendingClose) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.items[item.Mchid+"/"+item.OutTradeNo] = item
+	return nil
+}
+
+// Delete 删除待关闭订单。
+func (m *MemoryCloseStore) Delete(ctx context.Context, mchid, outTradeNo string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.items, mchid+"/"+outTradeNo)
+	return nil
+}
+
+// Due 返回已到期的待关闭订单，按 Deadline 升序。
+func (m *MemoryCloseStore) Due(ctx context.Context, now time.Time) ([]PendingClose, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var out []PendingClose
+	for _, item := range m.items {
+		if !item.Deadline.After(now) {
+			out = append(out, item)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Deadline.Before(out[j].Deadline) })
+	return out, nil
+}
@@ -0,0 +1,66 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/wneverfade/wechatpay-b2b/client"
+	"github.com/wneverfade/wechatpay-b2b/model"
+	"github.com/wneverfade/wechatpay-b2b/types"
+)
+
+// TestBuildCombinedPaymentParamsSignDataHasNoAppKey 确保下发给小程序的 signData 不包含任何子商户的 appKey，
+// 且每个子单的 paySig 使用各自的 appKey 计算。
+func TestBuildCombinedPaymentParamsSignDataHasNoAppKey(t *testing.T) {
+	c, err := client.NewClient(client.Options{AccessToken: "token", Env: model.EnvProd})
+	if err != nil {
+		t.Fatal(err)
+	}
+	appKeys := AppKeyMap{
+		"1900000001": "appkey-aaaaaaaaaaaaaaaaaaaaaaaaaaaa",
+		"1900000002": "appkey-bbbbbbbbbbbbbbbbbbbbbbbbbbbb",
+		"1900000003": "appkey-cccccccccccccccccccccccccccc",
+	}
+	req := types.CombinedPaymentSignData{Env: uint32(model.EnvProd)}
+	for i, mchid := range []string{"1900000001", "1900000002", "1900000003"} {
+		req.CombinedOrderList = append(req.CombinedOrderList, &types.CombinedOrder{
+			Mchid:       mchid,
+			OutTradeNo:  "T2024" + mchid,
+			Description: "子单",
+			Amount:      types.Amount{OrderAmount: model.Money(100 * (i + 1))},
+		})
+	}
+
+	params, err := NewOrderService(c).BuildCombinedPaymentParams(context.Background(), req, "session-key", appKeys)
+	if err != nil {
+		t.Fatalf("BuildCombinedPaymentParams: %v", err)
+	}
+
+	lower := strings.ToLower(params.SignData)
+	if strings.Contains(lower, "appkey") || strings.Contains(lower, "app_key") {
+		t.Fatalf("signData contains appKey field: %s", params.SignData)
+	}
+	var items []struct {
+		Mchid  string `json:"mchid"`
+		PaySig string `json:"paysig"`
+	}
+	if err := json.Unmarshal([]byte(params.PaySig), &items); err != nil {
+		t.Fatalf("unmarshal paySig: %v", err)
+	}
+	if len(items) != len(appKeys) {
+		t.Fatalf("got %d paySig items, want %d", len(items), len(appKeys))
+	}
+	for _, item := range items {
+		appKey := appKeys[item.Mchid]
+		for _, field := range []string{params.SignData, params.PaySig, params.Signature} {
+			if strings.Contains(field, appKey) {
+				t.Fatalf("payment params contain appKey of mchid %s", item.Mchid)
+			}
+		}
+		if want := client.GetPaySig(requestCommonPaymentURI, []byte(params.SignData), appKey); item.PaySig != want {
+			t.Fatalf("paySig of mchid %s not signed with its own appKey", item.Mchid)
+		}
+	}
+}
@@ -0,0 +1,222 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/wneverfade/wechatpay-b2b/model"
+	"github.com/wneverfade/wechatpay-b2b/types"
+)
+
+// retryableErrCodes 轮询时可重试的微信错误码：系统繁忙与接口调用频率超限。
+// 其它错误码（如订单不存在、签名错误）重试也不会成功；未拿到响应的网络或 HTTP 错误总是重试。
+var retryableErrCodes = map[int]bool{-1: true, 45009: true, 45011: true}
+
+const (
+	defaultWaitInterval    = time.Second
+	defaultWaitMaxInterval = 10 * time.Second
+	defaultWaitMultiplier  = 2
+)
+
+// WaitOptions 轮询等待参数，零值使用默认配置。
+type WaitOptions struct {
+	Interval    time.Duration // 首次轮询间隔，默认 1s
+	MaxInterval time.Duration // 最大轮询间隔，默认 10s
+	Multiplier  float64       // 每次轮询后间隔的放大倍数，默认 2
+}
+
+// PaymentEvent 表示一次订单轮询的结果。
+type PaymentEvent struct {
+	Order *types.GetOrderResponse // 查询结果，查询失败时可能为 nil
+	Err   error                   // 查询错误
+	Done  bool                    // 订单已进入支付成功或关闭等确定状态
+}
+
+// backoff 按 WaitOptions 生成逐次放大的轮询间隔。
+type backoff struct {
+	next       time.Duration
+	max        time.Duration
+	multiplier float64
+}
+
+func newBackoff(opts WaitOptions) *backoff {
+	b := &backoff{next: opts.Interval, max: opts.MaxInterval, multiplier: opts.Multiplier}
+	if b.next <= 0 {
+		b.next = defaultWaitInterval
+	}
+	if b.max <= 0 {
+		b.max = defaultWaitMaxInterval
+	}
+	if b.max < b.next {
+		b.max = b.next
+	}
+	if b.multiplier < 1 {
+		b.multiplier = defaultWaitMultiplier
+	}
+	return b
+}
+
+// wait 等待当前间隔并放大下一次间隔，ctx 结束时返回 ctx.Err()。
+func (b *backoff) wait(ctx context.Context) error {
+	timer := time.NewTimer(b.next)
+	defer timer.Stop()
+
+	b.next = time.Duration(float64(b.next) * b.multiplier)
+	if b.next > b.max {
+		b.next = b.max
+	}
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// WaitForPayment 轮询 GetOrder，直到订单支付成功（含退款中、已退款）、关闭或 ctx 结束。
+// 查询到的状态不能由上一次状态经 model.Transition 流转得到时视为乱序结果，丢弃后继续轮询。
+// 网络错误与 retryableErrCodes 中的错误码会继续重试，其它错误码立即返回；ctx 结束时返回最后一次查询结果及包装了 ctx.Err() 的错误。
+func (s *orderService) WaitForPayment(ctx context.Context, mchid, outTradeNo string, appKey string, opts WaitOptions) (*types.GetOrderResponse, error) {
+	if mchid == "" {
+		return nil, errors.New("mchid is required")
+	}
+	if outTradeNo == "" {
+		return nil, errors.New("out_trade_no is required")
+	}
+	if appKey == "" {
+		return nil, errors.New("appKey is empty")
+	}
+
+	var last PaymentEvent
+	err := s.pollPayment(ctx, mchid, outTradeNo, appKey, opts, func(e PaymentEvent) {
+		if e.Order != nil {
+			last.Order = e.Order
+		}
+		last.Err = e.Err
+	})
+	if err != nil {
+		if last.Err != nil && last.Err != err {
+			return last.Order, fmt.Errorf("%w (last error: %v)", err, last.Err)
+		}
+		return last.Order, err
+	}
+	return last.Order, nil
+}
+
+// WatchPayment 与 WaitForPayment 相同地轮询订单，并通过 channel 推送每次查询结果。
+// 订单进入确定状态（最后一个事件 Done 为 true）、查询返回不可重试的错误或 ctx 结束后关闭 channel。
+// 乱序结果以 Order 为空、Err 包装 model.ErrInvalidTransition 的事件推送，不会结束轮询。
+func (s *orderService) WatchPayment(ctx context.Context, mchid, outTradeNo string, appKey string, opts WaitOptions) <-chan PaymentEvent {
+	ch := make(chan PaymentEvent, 1)
+	go func() {
+		defer close(ch)
+		if mchid == "" || outTradeNo == "" {
+			ch <- PaymentEvent{Err: errors.New("mchid and out_trade_no are required")}
+			return
+		}
+		if appKey == "" {
+			ch <- PaymentEvent{Err: errors.New("appKey is empty")}
+			return
+		}
+		_ = s.pollPayment(ctx, mchid, outTradeNo, appKey, opts, func(e PaymentEvent) {
+			select {
+			case ch <- e:
+			case <-ctx.Done():
+			}
+		})
+	}()
+	return ch
+}
+
+// pollPayment 轮询订单并将每次结果交给 emit，订单进入确定状态时返回 nil，遇到不可重试的错误时返回该错误。
+func (s *orderService) pollPayment(ctx context.Context, mchid, outTradeNo string, appKey string, opts WaitOptions, emit func(PaymentEvent)) error {
+	if s.client == nil {
+		err := errors.New("client is nil")
+		emit(PaymentEvent{Err: err})
+		return err
+	}
+	req := types.GetOrderRequest{Mchid: mchid, OutTradeNo: outTradeNo}
+	b := newBackoff(opts)
+	var status model.PayStatus
+	for {
+		resp, err := s.GetOrder(ctx, req, appKey)
+		if err == nil && status != "" {
+			if terr := model.Transition(status, resp.PayStatus); errors.Is(terr, model.ErrInvalidTransition) {
+				emit(PaymentEvent{Err: fmt.Errorf("out_trade_no %s: %w", outTradeNo, terr)})
+				if err := b.wait(ctx); err != nil {
+					return err
+				}
+				continue
+			}
+		}
+		if err == nil {
+			status = resp.PayStatus
+		}
+		done := err == nil && (resp.PayStatus.IsPaid() || resp.PayStatus.IsTerminal())
+		emit(PaymentEvent{Order: resp, Err: err, Done: done})
+		if done {
+			return nil
+		}
+		if err != nil && resp != nil && !retryableErrCodes[resp.ErrCode] {
+			return err
+		}
+		if err := b.wait(ctx); err != nil {
+			return err
+		}
+	}
+}
+
+// WaitForRefund 轮询 GetRefund，直到退款成功、失败或 ctx 结束，错误重试与乱序结果的处理规则与 WaitForPayment 相同。
+// ctx 结束时返回最后一次查询结果及包装了 ctx.Err() 的错误。
+func (s *orderService) WaitForRefund(ctx context.Context, req types.GetRefundRequest, appKey string, opts WaitOptions) (*types.GetRefundResponse, error) {
+	if req.Mchid == "" {
+		return nil, errors.New("mchid is required")
+	}
+	if req.OutRefundNo == "" && req.RefundID == "" {
+		return nil, errors.New("out_refund_no or refund_id is required")
+	}
+	if s.client == nil {
+		return nil, errors.New("client is nil")
+	}
+	if appKey == "" {
+		return nil, errors.New("appKey is empty")
+	}
+
+	var (
+		last    *types.GetRefundResponse
+		lastErr error
+		status  model.RefundStatus
+	)
+	b := newBackoff(opts)
+	for {
+		resp, err := s.GetRefund(ctx, req, appKey)
+		if err == nil && status != "" {
+			if terr := model.TransitionRefund(status, resp.RefundStatus); errors.Is(terr, model.ErrInvalidTransition) {
+				// 乱序结果：丢弃后继续轮询。
+				resp, err = nil, terr
+			}
+		}
+		if err == nil {
+			status = resp.RefundStatus
+		}
+		if resp != nil {
+			last = resp
+		}
+		lastErr = err
+		if err == nil && resp.RefundStatus.IsTerminal() {
+			return resp, nil
+		}
+		if err != nil && resp != nil && !retryableErrCodes[resp.ErrCode] {
+			return resp, err
+		}
+		if err := b.wait(ctx); err != nil {
+			if lastErr != nil {
+				return last, fmt.Errorf("%w (last error: %v)", err, lastErr)
+			}
+			return last, err
+		}
+	}
+}
@@ -12,10 +12,6 @@ import (
 	"github.com/wneverfade/wechatpay-b2b/types"
 )
 
-// 未对接接口
-// QueryProfitSharingRemainAmt 查询分账剩余金额。
-// DelProfitSharingAccount 删除分账方。
-
 // ProfitService 处理分账相关调用。
 type ProfitService interface {
 	// ProfitSharing 请求分账。
@@ -30,8 +26,12 @@ type ProfitService interface {
 	QueryProfitSharingReturn(ctx context.Context, req types.QueryProfitSharingReturnRequest, appKey string) (*types.QueryProfitSharingReturnResponse, error)
 	// AddProfitSharingAccount 添加分账方。
 	AddProfitSharingAccount(ctx context.Context, req types.AddProfitSharingAccountRequest, appKey string) (*types.AddProfitSharingAccountResponse, error)
+	// DelProfitSharingAccount 删除分账方。
+	DelProfitSharingAccount(ctx context.Context, req types.DelProfitSharingAccountRequest, appKey string) (*types.DelProfitSharingAccountResponse, error)
 	// QueryProfitSharingAccount 查询分账方。
 	QueryProfitSharingAccount(ctx context.Context, req types.QueryProfitSharingAccountRequest, appKey string) (*types.QueryProfitSharingAccountResponse, error)
+	// QueryProfitSharingRemainAmt 查询分账剩余金额。
+	QueryProfitSharingRemainAmt(ctx context.Context, req types.QueryProfitSharingRemainAmtRequest, appKey string) (*types.QueryProfitSharingRemainAmtResponse, error)
 }
 
 type profitService struct {
@@ -52,11 +52,11 @@ const (
 	// 添加分账方接口
 	addProfitSharingAccountURI = "/retail/B2b/addprofitsharingaccount"
 	// 删除分账方接口
-	// delProfitSharingAccountURI = "/retail/B2b/delprofitsharingaccount"
+	delProfitSharingAccountURI = "/retail/B2b/delprofitsharingaccount"
 	// 查询分账方接口
 	queryProfitSharingAccountURI = "/retail/B2b/queryprofitsharingaccount"
 	// 查询分账剩余金额接口
-	// queryProfitSharingRemainAmtURI = "/retail/B2b/queryprofitsharingremainamt"
+	queryProfitSharingRemainAmtURI = "/retail/B2b/queryprofitsharingremainamt"
 )
 
 // NewProfitService 创建分账服务。
@@ -359,6 +359,52 @@ func (s *profitService) AddProfitSharingAccount(ctx context.Context, req types.A
 	return &out, nil
 }
 
+// DelProfitSharingAccount 删除分账方。
+func (s *profitService) DelProfitSharingAccount(ctx context.Context, req types.DelProfitSharingAccountRequest, appKey string) (*types.DelProfitSharingAccountResponse, error) {
+	if s.client == nil {
+		return nil, errors.New("client is nil")
+	}
+	if req.Mchid == "" {
+		return nil, errors.New("mchid is required")
+	}
+	if req.PayeeType == "" {
+		return nil, errors.New("payee_type is required")
+	}
+	if req.PayeeID == "" {
+		return nil, errors.New("payee_id is required")
+	}
+
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+
+	uri := s.client.BuildURIWithAuthAndSig(delProfitSharingAccountURI, body, appKey)
+
+	resp, err := s.client.Do(ctx, http.MethodPost, uri, body)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	raw, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		return nil, fmt.Errorf("wechat api http status %d: %s", resp.StatusCode, string(raw))
+	}
+
+	var out types.DelProfitSharingAccountResponse
+	if err := json.Unmarshal(raw, &out); err != nil {
+		return nil, err
+	}
+	if out.ErrCode != 0 {
+		return &out, fmt.Errorf("wechat api error: errcode=%d errmsg=%s", out.ErrCode, out.ErrMsg)
+	}
+	return &out, nil
+}
+
 // QueryProfitSharingAccount 查询分账方。
 func (s *profitService) QueryProfitSharingAccount(ctx context.Context, req types.QueryProfitSharingAccountRequest, appKey string) (*types.QueryProfitSharingAccountResponse, error) {
 	if s.client == nil {
@@ -401,3 +447,46 @@ func (s *profitService) QueryProfitSharingAccount(ctx context.Context, req types
 	}
 	return &out, nil
 }
+
+// QueryProfitSharingRemainAmt 查询分账剩余金额。
+func (s *profitService) QueryProfitSharingRemainAmt(ctx context.Context, req types.QueryProfitSharingRemainAmtRequest, appKey string) (*types.QueryProfitSharingRemainAmtResponse, error) {
+	if s.client == nil {
+		return nil, errors.New("client is nil")
+	}
+	if req.Mchid == "" {
+		return nil, errors.New("mchid is required")
+	}
+	if req.OutTradeNo == "" {
+		return nil, errors.New("out_trade_no is required")
+	}
+
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+
+	uri := s.client.BuildURIWithAuthAndSig(queryProfitSharingRemainAmtURI, body, appKey)
+
+	resp, err := s.client.Do(ctx, http.MethodPost, uri, body)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	raw, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		return nil, fmt.Errorf("wechat api http status %d: %s", resp.StatusCode, string(raw))
+	}
+
+	var out types.QueryProfitSharingRemainAmtResponse
+	if err := json.Unmarshal(raw, &out); err != nil {
+		return nil, err
+	}
+	if out.ErrCode != 0 {
+		return &out, fmt.Errorf("wechat api error: errcode=%d errmsg=%s", out.ErrCode, out.ErrMsg)
+	}
+	return &out, nil
+}
@@ -0,0 +1,367 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/wneverfade/wechatpay-b2b/model"
+	"github.com/wneverfade/wechatpay-b2b/types"
+)
+
+const (
+	defaultProfitMaxAttempts = 3
+	defaultProfitMaxPolls    = 10
+)
+
+var (
+	// ErrProfitIncomplete 分账尚未全部完成，可稍后调用 Resume 继续。
+	ErrProfitIncomplete = errors.New("profit sharing is incomplete")
+	// ErrProfitLegsMismatch 同一订单再次执行时传入的分账接收方或金额与已保存的进度不一致。
+	ErrProfitLegsMismatch = errors.New("profit sharing legs do not match saved progress")
+	// ErrProfitProgressNotFound 订单没有已保存的分账进度。
+	ErrProfitProgressNotFound = errors.New("profit sharing progress not found")
+)
+
+// ProfitLegState 表示单个分账接收方的执行状态。
+type ProfitLegState string
+
+const (
+	// ProfitLegPending 待提交（或上次提交结果未知）
+	ProfitLegPending ProfitLegState = "PENDING"
+	// ProfitLegSubmitted 已提交，等待分账结果
+	ProfitLegSubmitted ProfitLegState = "SUBMITTED"
+	// ProfitLegSucceeded 分账成功
+	ProfitLegSucceeded ProfitLegState = "SUCCEEDED"
+	// ProfitLegFailed 分账失败
+	ProfitLegFailed ProfitLegState = "FAILED"
+)
+
+// ProfitLeg 表示订单中单个分账接收方的进度。
+type ProfitLeg struct {
+	ReceiverType    model.ProfitReceiverType `json:"receiver_type"`
+	ReceiverAccount string                   `json:"receiver_account"`
+	Amount          model.Money              `json:"amount"`
+	State           ProfitLegState           `json:"state"`
+	Attempts        int                      `json:"attempts"`             // 已提交 ProfitSharing 的次数
+	LastError       string                   `json:"last_error,omitempty"` // 最近一次错误
+	UpdatedAt       time.Time                `json:"updated_at"`
+}
+
+// ProfitProgress 表示一笔订单的分账进度。
+type ProfitProgress struct {
+	Mchid      string      `json:"mchid"`
+	OutTradeNo string      `json:"out_trade_no"`
+	Legs       []ProfitLeg `json:"legs"`
+	Finished   bool        `json:"finished"` // 已调用 ProfitSharingFinish
+	CreatedAt  time.Time   `json:"created_at"`
+	UpdatedAt  time.Time   `json:"updated_at"`
+}
+
+// Succeeded 判断所有接收方是否均已分账成功。
+func (p *ProfitProgress) Succeeded() bool {
+	for _, leg := range p.Legs {
+		if leg.State != ProfitLegSucceeded {
+			return false
+		}
+	}
+	return true
+}
+
+// ProfitProgressStore 持久化分账进度，保证进程重启后可以继续执行。
+type ProfitProgressStore interface {
+	// Load 读取订单的分账进度，不存在时返回 nil, nil。
+	Load(ctx context.Context, mchid, outTradeNo string) (*ProfitProgress, error)
+	// Save 新增或更新订单的分账进度（以 mchid + out_trade_no 为键）。
+	Save(ctx context.Context, progress ProfitProgress) error
+}
+
+// ProfitOrchestratorOptions 分账编排参数，零值使用默认配置。
+type ProfitOrchestratorOptions struct {
+	MaxAttempts int              // 单个接收方最多提交 ProfitSharing 的次数，默认 3
+	MaxPolls    int              // 单次执行中每个接收方最多查询分账结果的次数，默认 10
+	Wait        WaitOptions      // 查询分账结果的轮询间隔
+	Now         func() time.Time // 当前时间，默认 time.Now
+}
+
+// ProfitOrchestrator 按接收方逐个请求分账、查询结果，全部成功后完结分账。
+// 每一步前后都会保存进度；提交结果未知的接收方会先查询再决定是否重新提交，避免重复分账。
+type ProfitOrchestrator struct {
+	profit  ProfitService
+	store   ProfitProgressStore
+	appKeys AppKeyResolver
+	opts    ProfitOrchestratorOptions
+}
+
+// NewProfitOrchestrator 创建分账编排器。
+func NewProfitOrchestrator(profit ProfitService, store ProfitProgressStore, appKeys AppKeyResolver, opts ProfitOrchestratorOptions) *ProfitOrchestrator {
+	if opts.MaxAttempts <= 0 {
+		opts.MaxAttempts = defaultProfitMaxAttempts
+	}
+	if opts.MaxPolls <= 0 {
+		opts.MaxPolls = defaultProfitMaxPolls
+	}
+	if opts.Now == nil {
+		opts.Now = time.Now
+	}
+	return &ProfitOrchestrator{profit: profit, store: store, appKeys: appKeys, opts: opts}
+}
+
+// Execute 对订单执行分账，legs 通常来自 ProfitPlan.Legs。
+// 订单已有进度时 legs 必须与之一致，此时等同于 Resume。未全部完成时返回当前进度及 ErrProfitIncomplete。
+func (o *ProfitOrchestrator) Execute(ctx context.Context, mchid, outTradeNo string, legs []model.ProfitReceiver) (*ProfitProgress, error) {
+	if o.profit == nil || o.store == nil || o.appKeys == nil {
+		return nil, errors.New("profit orchestrator is not initialized")
+	}
+	if mchid == "" {
+		return nil, errors.New("mchid is required")
+	}
+	if outTradeNo == "" {
+		return nil, errors.New("out_trade_no is required")
+	}
+	if len(legs) == 0 {
+		return nil, errors.New("legs is required")
+	}
+
+	progress, err := o.store.Load(ctx, mchid, outTradeNo)
+	if err != nil {
+		return nil, err
+	}
+	if progress != nil {
+		if !legsMatch(progress.Legs, legs) {
+			return progress, ErrProfitLegsMismatch
+		}
+		return o.run(ctx, progress)
+	}
+
+	now := o.opts.Now()
+	progress = &ProfitProgress{Mchid: mchid, OutTradeNo: outTradeNo, CreatedAt: now, UpdatedAt: now}
+	seen := make(map[string]bool, len(legs))
+	for i, leg := range legs {
+		if leg.Type == "" || leg.Account == "" {
+			return nil, fmt.Errorf("legs[%d]: receiver type and account are required", i)
+		}
+		if leg.Amount <= 0 {
+			return nil, fmt.Errorf("legs[%d]: amount must be positive", i)
+		}
+		key := string(leg.Type) + "/" + leg.Account
+		if seen[key] {
+			return nil, fmt.Errorf("legs[%d]: duplicate receiver %s", i, leg.Account)
+		}
+		seen[key] = true
+		progress.Legs = append(progress.Legs, ProfitLeg{
+			ReceiverType:    leg.Type,
+			ReceiverAccount: leg.Account,
+			Amount:          leg.Amount,
+			State:           ProfitLegPending,
+			UpdatedAt:       now,
+		})
+	}
+	if err := o.store.Save(ctx, *progress); err != nil {
+		return nil, err
+	}
+	return o.run(ctx, progress)
+}
+
+// Resume 继续执行已保存的分账进度，如进程重启后或 ErrProfitIncomplete 之后调用。
+func (o *ProfitOrchestrator) Resume(ctx context.Context, mchid, outTradeNo string) (*ProfitProgress, error) {
+	if o.profit == nil || o.store == nil || o.appKeys == nil {
+		return nil, errors.New("profit orchestrator is not initialized")
+	}
+	progress, err := o.store.Load(ctx, mchid, outTradeNo)
+	if err != nil {
+		return nil, err
+	}
+	if progress == nil {
+		return nil, fmt.Errorf("%w: out_trade_no %s", ErrProfitProgressNotFound, outTradeNo)
+	}
+	return o.run(ctx, progress)
+}
+
+// run 推进所有未完成的接收方，全部成功后完结分账。
+func (o *ProfitOrchestrator) run(ctx context.Context, progress *ProfitProgress) (*ProfitProgress, error) {
+	if progress.Finished {
+		return progress, nil
+	}
+	appKey, err := o.appKeys.AppKey(ctx, progress.Mchid)
+	if err != nil {
+		return progress, err
+	}
+
+	for i := range progress.Legs {
+		if err := o.advance(ctx, progress, &progress.Legs[i], appKey); err != nil {
+			return progress, err
+		}
+	}
+	if !progress.Succeeded() {
+		return progress, o.incomplete(progress)
+	}
+
+	_, err = o.profit.ProfitSharingFinish(ctx, types.ProfitSharingFinishRequest{
+		Mchid:      progress.Mchid,
+		OutTradeNo: progress.OutTradeNo,
+	}, appKey)
+	if err != nil {
+		return progress, fmt.Errorf("profit sharing finish: %w", err)
+	}
+	progress.Finished = true
+	return progress, o.save(ctx, progress)
+}
+
+// advance 推进单个接收方，仅在读写存储失败或 ctx 结束时返回错误，接口错误记录在 leg 中。
+func (o *ProfitOrchestrator) advance(ctx context.Context, progress *ProfitProgress, leg *ProfitLeg, appKey string) error {
+	// 已提交过但结果未知时先查询，避免重复分账。
+	if leg.State == ProfitLegPending && leg.Attempts > 0 {
+		o.query(ctx, progress, leg, appKey)
+		if err := o.save(ctx, progress); err != nil {
+			return err
+		}
+	}
+	if leg.State == ProfitLegFailed && leg.Attempts < o.opts.MaxAttempts {
+		leg.State = ProfitLegPending
+	}
+
+	if leg.State == ProfitLegPending {
+		if leg.Attempts >= o.opts.MaxAttempts {
+			return nil
+		}
+		// 提交前先记录次数，进程在提交后崩溃时下次会先查询。
+		leg.Attempts++
+		if err := o.save(ctx, progress); err != nil {
+			return err
+		}
+		resp, err := o.profit.ProfitSharing(ctx, types.ProfitSharingRequest{
+			Mchid:           progress.Mchid,
+			OutTradeNo:      progress.OutTradeNo,
+			ProfitFee:       leg.Amount,
+			ReceiverType:    leg.ReceiverType,
+			ReceiverAccount: leg.ReceiverAccount,
+		}, appKey)
+		switch {
+		case err == nil:
+			leg.State, leg.LastError = ProfitLegSubmitted, ""
+		case resp != nil:
+			// 接口明确返回错误，本次提交未受理。
+			leg.State, leg.LastError = ProfitLegFailed, err.Error()
+		default:
+			// 网络错误等结果未知的情况保持待提交，下次先查询。
+			leg.LastError = err.Error()
+		}
+		leg.UpdatedAt = o.opts.Now()
+		if err := o.save(ctx, progress); err != nil {
+			return err
+		}
+	}
+
+	if leg.State != ProfitLegSubmitted {
+		return nil
+	}
+	b := newBackoff(o.opts.Wait)
+	for polls := 0; polls < o.opts.MaxPolls; polls++ {
+		if polls > 0 {
+			if err := b.wait(ctx); err != nil {
+				return err
+			}
+		}
+		o.query(ctx, progress, leg, appKey)
+		if leg.State != ProfitLegSubmitted {
+			break
+		}
+	}
+	return o.save(ctx, progress)
+}
+
+// query 查询分账结果并更新 leg 状态，查询失败或处理中时状态不变。
+func (o *ProfitOrchestrator) query(ctx context.Context, progress *ProfitProgress, leg *ProfitLeg, appKey string) {
+	resp, err := o.profit.QueryProfitSharing(ctx, types.QueryProfitSharingRequest{
+		Mchid:           progress.Mchid,
+		OutTradeNo:      progress.OutTradeNo,
+		ReceiverType:    leg.ReceiverType,
+		ReceiverAccount: leg.ReceiverAccount,
+	}, appKey)
+	if err != nil {
+		leg.LastError = err.Error()
+		return
+	}
+	switch resp.OrderStatus {
+	case model.ProfitStatusSuccess:
+		leg.State, leg.LastError = ProfitLegSucceeded, ""
+	case model.ProfitStatusFailed:
+		leg.State, leg.LastError = ProfitLegFailed, "profit sharing failed"
+	case model.ProfitStatusInit:
+		if leg.State == ProfitLegPending {
+			leg.State = ProfitLegSubmitted
+		}
+	default:
+		leg.LastError = fmt.Sprintf("%v: order_status %v", model.ErrUnknownStatus, resp.OrderStatus)
+	}
+	leg.UpdatedAt = o.opts.Now()
+}
+
+func (o *ProfitOrchestrator) save(ctx context.Context, progress *ProfitProgress) error {
+	progress.UpdatedAt = o.opts.Now()
+	return o.store.Save(ctx, *progress)
+}
+
+// incomplete 汇总未完成接收方的错误。
+func (o *ProfitOrchestrator) incomplete(progress *ProfitProgress) error {
+	errs := []error{ErrProfitIncomplete}
+	for _, leg := range progress.Legs {
+		if leg.State != ProfitLegSucceeded && leg.LastError != "" {
+			errs = append(errs, fmt.Errorf("receiver %s: %s", leg.ReceiverAccount, leg.LastError))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// legsMatch 判断传入的接收方及金额是否与已保存的进度一致（不要求顺序相同）。
+func legsMatch(saved []ProfitLeg, legs []model.ProfitReceiver) bool {
+	if len(saved) != len(legs) {
+		return false
+	}
+	amounts := make(map[string]model.Money, len(saved))
+	for _, leg := range saved {
+		amounts[string(leg.ReceiverType)+"/"+leg.ReceiverAccount] = leg.Amount
+	}
+	for _, leg := range legs {
+		amount, ok := amounts[string(leg.Type)+"/"+leg.Account]
+		if !ok || amount != leg.Amount {
+			return false
+		}
+	}
+	return true
+}
+
+// MemoryProfitProgressStore 是 ProfitProgressStore 的内存实现，进程重启后数据会丢失，适用于测试或单机场景。
+type MemoryProfitProgressStore struct {
+	mu    sync.Mutex
+	items map[string]ProfitProgress
+}
+
+// NewMemoryProfitProgressStore 创建内存分账进度存储。
+func NewMemoryProfitProgressStore() *MemoryProfitProgressStore {
+	return &MemoryProfitProgressStore{items: make(map[string]ProfitProgress)}
+}
+
+// Load 读取订单的分账进度。
+func (m *MemoryProfitProgressStore) Load(ctx context.Context, mchid, outTradeNo string) (*ProfitProgress, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	item, ok := m.items[mchid+"/"+outTradeNo]
+	if !ok {
+		return nil, nil
+	}
+	item.Legs = append([]ProfitLeg(nil), item.Legs...)
+	return &item, nil
+}
+
+// Save 新增或更新订单的分账进度。
+func (m *MemoryProfitProgressStore) Save(ctx context.Context, progress ProfitProgress) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	progress.Legs = append([]ProfitLeg(nil), progress.Legs...)
+	m.items[progress.Mchid+"/"+progress.OutTradeNo] = progress
+	return nil
+}
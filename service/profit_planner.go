@@ -0,0 +1,257 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+
+	"github.com/wneverfade/wechatpay-b2b/model"
+	"github.com/wneverfade/wechatpay-b2b/types"
+)
+
+// percentBase 比例规则以万分比表示，10000 即 100%。
+const percentBase = 10000
+
+// ProfitRule 表示一个分账接收方的分账规则，金额 = Fixed + 基数 × Percent / 10000，再受 Cap 限制。
+// 可按 Receiver.Relation（门店、服务商、员工等）分别配置比例、固定金额、上限与优先级。
+type ProfitRule struct {
+	Receiver model.ProfitReceiver // 分账接收方，Amount 字段由规划结果填充
+	Percent  int                  // 按订单金额的万分比，如 1000 表示 10%
+	Fixed    model.Money          // 固定金额
+	Cap      model.Money          // 金额上限，0 表示不限
+	Priority int                  // 可分金额不足时数值小的优先满足，相同时按规则顺序
+}
+
+// ProfitPlanInput 分账规划的金额输入。OrderAmount 与 RemainAmount 至少填写一个。
+type ProfitPlanInput struct {
+	OrderAmount  model.Money  // 订单金额，比例规则的计算基数；为 0 时以 RemainAmount 为基数
+	PlatformFee  model.Money  // 技术服务费，不可参与分账
+	RemainAmount *model.Money // 剩余可分账金额（QueryProfitSharingRemainAmt），为空时按 OrderAmount - PlatformFee
+}
+
+// base 返回比例规则的计算基数。
+func (in ProfitPlanInput) base() model.Money {
+	if in.OrderAmount == 0 && in.RemainAmount != nil {
+		return *in.RemainAmount
+	}
+	return in.OrderAmount
+}
+
+// Available 返回可参与分账的金额：OrderAmount - PlatformFee 与 RemainAmount 中的较小值。
+func (in ProfitPlanInput) Available() (model.Money, error) {
+	if in.OrderAmount == 0 && in.RemainAmount != nil {
+		return max(*in.RemainAmount, 0), nil
+	}
+	available, err := in.OrderAmount.Sub(in.PlatformFee)
+	if err != nil {
+		return 0, err
+	}
+	if in.RemainAmount != nil && *in.RemainAmount < available {
+		available = *in.RemainAmount
+	}
+	if available < 0 {
+		available = 0
+	}
+	return available, nil
+}
+
+// ProfitPlan 表示分账规划结果。
+type ProfitPlan struct {
+	Legs      []model.ProfitReceiver // 各接收方分账金额，金额为 0 的接收方不会出现
+	Total     model.Money            // 分账总额
+	Available model.Money            // 可参与分账的金额
+}
+
+// PlanProfitSharing 按规则计算各接收方的分账金额。
+// 比例部分按基数向下取整到分，舍去的分按最大余数法补给比例规则，使比例部分之和等于 floor(基数 × 总比例 / 10000)；
+// 余数相同时按 Priority、规则顺序决定。可分金额不足时按 Priority 依次分配。
+func PlanProfitSharing(in ProfitPlanInput, rules []ProfitRule) (*ProfitPlan, error) {
+	if in.OrderAmount < 0 || in.base() <= 0 {
+		return nil, errors.New("order_amount or remain_amount must be positive")
+	}
+	if in.PlatformFee < 0 {
+		return nil, errors.New("platform_fee must not be negative")
+	}
+	if len(rules) == 0 {
+		return nil, errors.New("rules is required")
+	}
+	if err := validateProfitRules(rules); err != nil {
+		return nil, err
+	}
+	available, err := in.Available()
+	if err != nil {
+		return nil, err
+	}
+
+	percents, err := allocatePercents(in.base(), rules)
+	if err != nil {
+		return nil, err
+	}
+
+	desired := make([]model.Money, len(rules))
+	for i, rule := range rules {
+		if desired[i], err = rule.Fixed.Add(percents[i]); err != nil {
+			return nil, err
+		}
+		if rule.Cap > 0 && desired[i] > rule.Cap {
+			desired[i] = rule.Cap
+		}
+	}
+
+	order := make([]int, len(rules))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool { return rules[order[a]].Priority < rules[order[b]].Priority })
+
+	amounts := make([]model.Money, len(rules))
+	left := available
+	for _, i := range order {
+		amounts[i] = min(desired[i], left)
+		left -= amounts[i]
+	}
+
+	plan := &ProfitPlan{Available: available}
+	for i, rule := range rules {
+		if amounts[i] == 0 {
+			continue
+		}
+		leg := rule.Receiver
+		leg.Amount = amounts[i]
+		plan.Legs = append(plan.Legs, leg)
+		plan.Total += amounts[i]
+	}
+	return plan, plan.Validate(in)
+}
+
+// PlanProfitSharingFromRemain 先通过 QueryProfitSharingRemainAmt 查询剩余可分账金额，再按规则规划分账。
+func PlanProfitSharingFromRemain(ctx context.Context, profit ProfitService, req types.QueryProfitSharingRemainAmtRequest, appKey string, in ProfitPlanInput, rules []ProfitRule) (*ProfitPlan, error) {
+	if profit == nil {
+		return nil, errors.New("profit service is nil")
+	}
+	resp, err := profit.QueryProfitSharingRemainAmt(ctx, req, appKey)
+	if err != nil {
+		return nil, err
+	}
+	remain := resp.RemainAmount
+	in.RemainAmount = &remain
+	return PlanProfitSharing(in, rules)
+}
+
+// Validate 校验分账结果：每笔金额为正、接收方不重复，且总额不超过可分账金额。
+func (p *ProfitPlan) Validate(in ProfitPlanInput) error {
+	available, err := in.Available()
+	if err != nil {
+		return err
+	}
+	seen := make(map[string]bool, len(p.Legs))
+	amounts := make([]model.Money, 0, len(p.Legs))
+	for _, leg := range p.Legs {
+		if leg.Amount <= 0 {
+			return fmt.Errorf("amount of receiver %s must be positive", leg.Account)
+		}
+		key := string(leg.Type) + "/" + leg.Account
+		if seen[key] {
+			return fmt.Errorf("duplicate receiver %s", leg.Account)
+		}
+		seen[key] = true
+		amounts = append(amounts, leg.Amount)
+	}
+	total, err := model.SumMoney(amounts...)
+	if err != nil {
+		return err
+	}
+	if total != p.Total {
+		return fmt.Errorf("plan total %d does not equal sum of legs %d", p.Total, total)
+	}
+	if total > available {
+		return fmt.Errorf("plan total %d exceeds available amount %d", total, available)
+	}
+	return nil
+}
+
+// Requests 将分账结果转换为逐个接收方的 ProfitSharing 请求。
+func (p *ProfitPlan) Requests(mchid, outTradeNo string) []types.ProfitSharingRequest {
+	reqs := make([]types.ProfitSharingRequest, 0, len(p.Legs))
+	for _, leg := range p.Legs {
+		reqs = append(reqs, types.ProfitSharingRequest{
+			Mchid:           mchid,
+			OutTradeNo:      outTradeNo,
+			ProfitFee:       leg.Amount,
+			ReceiverType:    leg.Type,
+			ReceiverAccount: leg.Account,
+		})
+	}
+	return reqs
+}
+
+// validateProfitRules 校验分账规则。
+func validateProfitRules(rules []ProfitRule) error {
+	seen := make(map[string]bool, len(rules))
+	totalPercent := 0
+	for i, rule := range rules {
+		r := rule.Receiver
+		if r.Type == "" || r.Account == "" {
+			return fmt.Errorf("rules[%d]: receiver type and account are required", i)
+		}
+		key := string(r.Type) + "/" + r.Account
+		if seen[key] {
+			return fmt.Errorf("rules[%d]: duplicate receiver %s", i, r.Account)
+		}
+		seen[key] = true
+		if rule.Percent < 0 || rule.Percent > percentBase {
+			return fmt.Errorf("rules[%d]: percent must be between 0 and %d", i, percentBase)
+		}
+		if rule.Fixed < 0 || rule.Cap < 0 {
+			return fmt.Errorf("rules[%d]: fixed and cap must not be negative", i)
+		}
+		totalPercent += rule.Percent
+	}
+	if totalPercent > percentBase {
+		return fmt.Errorf("total percent %d exceeds %d", totalPercent, percentBase)
+	}
+	return nil
+}
+
+// allocatePercents 按基数计算各规则的比例金额。
+func allocatePercents(base model.Money, rules []ProfitRule) ([]model.Money, error) {
+	type remainder struct {
+		index int
+		value *big.Int
+	}
+	bigBase := big.NewInt(int64(base))
+	divisor := big.NewInt(percentBase)
+
+	shares := make([]model.Money, len(rules))
+	rems := make([]remainder, 0, len(rules))
+	totalPercent := 0
+	var allocated model.Money
+	for i, rule := range rules {
+		if rule.Percent == 0 {
+			continue
+		}
+		totalPercent += rule.Percent
+		q, m := new(big.Int).QuoRem(new(big.Int).Mul(bigBase, big.NewInt(int64(rule.Percent))), divisor, new(big.Int))
+		shares[i] = model.Money(q.Int64())
+		allocated += shares[i]
+		rems = append(rems, remainder{index: i, value: m})
+	}
+
+	target := new(big.Int).Quo(new(big.Int).Mul(bigBase, big.NewInt(int64(totalPercent))), divisor)
+	if !target.IsInt64() {
+		return nil, model.ErrMoneyOverflow
+	}
+	sort.SliceStable(rems, func(a, b int) bool {
+		if c := rems[a].value.Cmp(rems[b].value); c != 0 {
+			return c > 0
+		}
+		return rules[rems[a].index].Priority < rules[rems[b].index].Priority
+	})
+	for i := 0; allocated < model.Money(target.Int64()); i++ {
+		shares[rems[i].index]++
+		allocated++
+	}
+	return shares, nil
+}
@@ -0,0 +1,291 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	"github.com/wneverfade/wechatpay-b2b/model"
+	"github.com/wneverfade/wechatpay-b2b/types"
+)
+
+// queryProfitSharingAccountMaxLimit QueryProfitSharingAccount 单页最大条数。
+const queryProfitSharingAccountMaxLimit = 100
+
+// DesiredReceiver 表示期望存在的分账接收方，通常来自业务配置。
+type DesiredReceiver struct {
+	Type     model.ProfitReceiverType         `json:"type"`
+	Account  string                           `json:"account"`
+	Name     string                           `json:"name"`
+	Relation model.ProfitReceiverRelationType `json:"relation"`
+}
+
+// ReceiverSyncAction 表示分账接收方同步动作。
+type ReceiverSyncAction string
+
+const (
+	// ReceiverSyncAdd 添加缺少的接收方
+	ReceiverSyncAdd ReceiverSyncAction = "ADD"
+	// ReceiverSyncUpdate 名称不一致，重新添加以更新
+	ReceiverSyncUpdate ReceiverSyncAction = "UPDATE"
+	// ReceiverSyncRemove 删除配置中不存在的接收方
+	ReceiverSyncRemove ReceiverSyncAction = "REMOVE"
+)
+
+// ReceiverChange 表示一条同步变更及执行结果。
+type ReceiverChange struct {
+	Action  ReceiverSyncAction       `json:"action"`
+	Type    model.ProfitReceiverType `json:"type"`
+	Account string                   `json:"account"`
+	Name    string                   `json:"name,omitempty"`
+	// Relation 仅 ADD/UPDATE 时有值。
+	Relation model.ProfitReceiverRelationType `json:"relation,omitempty"`
+	// CurrentName、CurrentRelation 为微信侧当前的名称与关系类型，仅 UPDATE/REMOVE 时有值。
+	CurrentName     string                           `json:"current_name,omitempty"`
+	CurrentRelation model.ProfitReceiverRelationType `json:"current_relation,omitempty"`
+	Applied         bool                             `json:"applied"`
+	Error           string                           `json:"error,omitempty"`
+}
+
+// ReceiverSyncPlan 表示分账接收方同步计划。
+type ReceiverSyncPlan struct {
+	Mchid   string                       `json:"mchid"`
+	Current []types.ProfitSharingAccount `json:"current"` // 微信侧当前的接收方
+	Changes []ReceiverChange             `json:"changes"`
+}
+
+// WriteText 以一行一条变更的可读格式输出计划，用于 dry-run 预览。
+func (p *ReceiverSyncPlan) WriteText(w io.Writer) error {
+	if len(p.Changes) == 0 {
+		_, err := fmt.Fprintf(w, "mchid %s: %d receivers, no changes\n", p.Mchid, len(p.Current))
+		return err
+	}
+	for _, c := range p.Changes {
+		var line string
+		switch c.Action {
+		case ReceiverSyncAdd:
+			line = fmt.Sprintf("+ %s %s %q (%s)", c.Type, c.Account, c.Name, c.Relation)
+		case ReceiverSyncUpdate:
+			line = fmt.Sprintf("~ %s %s %q (%s) -> %q (%s)", c.Type, c.Account, c.CurrentName, c.CurrentRelation, c.Name, c.Relation)
+		default:
+			line = fmt.Sprintf("- %s %s %q (%s)", c.Type, c.Account, c.CurrentName, c.CurrentRelation)
+		}
+		if _, err := fmt.Fprintln(w, line); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ReceiverSyncAudit 记录一次同步的计划与执行结果。
+type ReceiverSyncAudit struct {
+	Mchid      string           `json:"mchid"`
+	DryRun     bool             `json:"dry_run"`
+	StartedAt  time.Time        `json:"started_at"`
+	FinishedAt time.Time        `json:"finished_at"`
+	Changes    []ReceiverChange `json:"changes"`
+}
+
+// Err 汇总执行失败的变更，全部成功时返回 nil。
+func (a *ReceiverSyncAudit) Err() error {
+	var errs []error
+	for _, c := range a.Changes {
+		if c.Error != "" {
+			errs = append(errs, fmt.Errorf("%s %s: %s", c.Action, c.Account, c.Error))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// WriteJSON 以 JSON 格式输出审计记录。
+func (a *ReceiverSyncAudit) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(a)
+}
+
+// ReceiverSyncOptions 分账接收方同步参数。
+type ReceiverSyncOptions struct {
+	DryRun      bool // 只生成计划与审计记录，不调用添加、删除接口
+	KeepUnknown bool // 不删除配置中不存在的接收方
+	// TypeOf 返回配置中不存在的接收方的类型，删除时作为 payee_type。
+	// QueryProfitSharingAccount 不返回接收方类型，未设置或返回空值时删除变更记为失败，不会调用删除接口。
+	TypeOf  func(types.ProfitSharingAccount) model.ProfitReceiverType
+	OnAudit func(ReceiverSyncAudit) // 每次 Sync 完成后回调，用于持久化审计记录
+	Now     func() time.Time        // 当前时间，默认 time.Now
+}
+
+// ReceiverSyncer 将微信侧的分账接收方与期望配置保持一致。
+// QueryProfitSharingAccount 只返回接收方账号与关系类型（sharing_account_type），因此以 sharing_account 作为接收方的唯一键，
+// 名称或关系类型不一致时更新。
+type ReceiverSyncer struct {
+	profit ProfitService
+	opts   ReceiverSyncOptions
+}
+
+// NewReceiverSyncer 创建分账接收方同步器。
+func NewReceiverSyncer(profit ProfitService, opts ReceiverSyncOptions) *ReceiverSyncer {
+	if opts.Now == nil {
+		opts.Now = time.Now
+	}
+	return &ReceiverSyncer{profit: profit, opts: opts}
+}
+
+// ListReceivers 分页查询全部分账接收方。某页没有新的接收方时视为接口忽略了 offset，停止翻页。
+func (s *ReceiverSyncer) ListReceivers(ctx context.Context, appKey string) ([]types.ProfitSharingAccount, error) {
+	if s.profit == nil {
+		return nil, errors.New("profit service is nil")
+	}
+	var out []types.ProfitSharingAccount
+	seen := make(map[string]bool)
+	for offset := 0; ; offset += queryProfitSharingAccountMaxLimit {
+		resp, err := s.profit.QueryProfitSharingAccount(ctx, types.QueryProfitSharingAccountRequest{
+			Offset: offset,
+			Limit:  queryProfitSharingAccountMaxLimit,
+		}, appKey)
+		if err != nil {
+			return nil, fmt.Errorf("query profit sharing account at offset %d: %w", offset, err)
+		}
+		fresh := 0
+		for _, a := range resp.AccountList {
+			if seen[a.SharingAccount] {
+				continue
+			}
+			seen[a.SharingAccount] = true
+			out = append(out, a)
+			fresh++
+		}
+		if fresh == 0 || len(resp.AccountList) < queryProfitSharingAccountMaxLimit {
+			return out, nil
+		}
+	}
+}
+
+// Plan 对比期望配置与微信侧当前的接收方，生成同步计划，不发起变更。
+func (s *ReceiverSyncer) Plan(ctx context.Context, mchid, appKey string, desired []DesiredReceiver) (*ReceiverSyncPlan, error) {
+	if mchid == "" {
+		return nil, errors.New("mchid is required")
+	}
+	want := make(map[string]DesiredReceiver, len(desired))
+	for i, d := range desired {
+		if d.Type == "" || d.Account == "" {
+			return nil, fmt.Errorf("desired[%d]: type and account are required", i)
+		}
+		if d.Name == "" {
+			return nil, fmt.Errorf("desired[%d]: name is required", i)
+		}
+		if d.Relation == "" {
+			return nil, fmt.Errorf("desired[%d]: relation is required", i)
+		}
+		if _, ok := want[d.Account]; ok {
+			return nil, fmt.Errorf("desired[%d]: duplicate receiver %s", i, d.Account)
+		}
+		want[d.Account] = d
+	}
+
+	current, err := s.ListReceivers(ctx, appKey)
+	if err != nil {
+		return nil, err
+	}
+	plan := &ReceiverSyncPlan{Mchid: mchid, Current: current}
+	have := make(map[string]types.ProfitSharingAccount, len(current))
+	for _, a := range current {
+		have[a.SharingAccount] = a
+		if _, ok := want[a.SharingAccount]; !ok && !s.opts.KeepUnknown {
+			change := ReceiverChange{
+				Action:          ReceiverSyncRemove,
+				Account:         a.SharingAccount,
+				CurrentName:     a.Name,
+				CurrentRelation: model.ProfitReceiverRelationType(a.SharingAccountType),
+			}
+			if s.opts.TypeOf != nil {
+				change.Type = s.opts.TypeOf(a)
+			}
+			plan.Changes = append(plan.Changes, change)
+		}
+	}
+	for _, d := range desired {
+		change := ReceiverChange{Action: ReceiverSyncAdd, Type: d.Type, Account: d.Account, Name: d.Name, Relation: d.Relation}
+		if a, ok := have[d.Account]; ok {
+			if a.Name == d.Name && a.SharingAccountType == string(d.Relation) {
+				continue
+			}
+			change.Action = ReceiverSyncUpdate
+			change.CurrentName, change.CurrentRelation = a.Name, model.ProfitReceiverRelationType(a.SharingAccountType)
+		}
+		plan.Changes = append(plan.Changes, change)
+	}
+
+	// 先添加、更新再删除，避免商户在同步过程中没有可用的接收方；按接收方账号排序保证输出稳定。
+	order := map[ReceiverSyncAction]int{ReceiverSyncAdd: 0, ReceiverSyncUpdate: 1, ReceiverSyncRemove: 2}
+	sort.SliceStable(plan.Changes, func(i, j int) bool {
+		a, b := plan.Changes[i], plan.Changes[j]
+		if order[a.Action] != order[b.Action] {
+			return order[a.Action] < order[b.Action]
+		}
+		if a.Type != b.Type {
+			return a.Type < b.Type
+		}
+		return a.Account < b.Account
+	})
+	return plan, nil
+}
+
+// Sync 生成同步计划并依次执行，DryRun 时只生成审计记录。单条变更失败不会中断其它变更，失败汇总在审计记录中。
+func (s *ReceiverSyncer) Sync(ctx context.Context, mchid, appKey string, desired []DesiredReceiver) (*ReceiverSyncAudit, error) {
+	audit := &ReceiverSyncAudit{Mchid: mchid, DryRun: s.opts.DryRun, StartedAt: s.opts.Now()}
+	plan, err := s.Plan(ctx, mchid, appKey, desired)
+	if err != nil {
+		return nil, err
+	}
+	audit.Changes = plan.Changes
+
+	if !s.opts.DryRun {
+		for i := range audit.Changes {
+			if err := ctx.Err(); err != nil {
+				audit.Changes[i].Error = err.Error()
+				continue
+			}
+			s.apply(ctx, mchid, appKey, &audit.Changes[i])
+		}
+	}
+
+	audit.FinishedAt = s.opts.Now()
+	if s.opts.OnAudit != nil {
+		s.opts.OnAudit(*audit)
+	}
+	return audit, audit.Err()
+}
+
+// apply 执行单条变更。
+func (s *ReceiverSyncer) apply(ctx context.Context, mchid, appKey string, c *ReceiverChange) {
+	var err error
+	switch c.Action {
+	case ReceiverSyncRemove:
+		if c.Type == "" {
+			c.Error = "receiver type is unknown, set ReceiverSyncOptions.TypeOf"
+			return
+		}
+		_, err = s.profit.DelProfitSharingAccount(ctx, types.DelProfitSharingAccountRequest{
+			Mchid:     mchid,
+			PayeeType: c.Type,
+			PayeeID:   c.Account,
+		}, appKey)
+	default:
+		_, err = s.profit.AddProfitSharingAccount(ctx, types.AddProfitSharingAccountRequest{
+			ProfitSharingRelationType: c.Relation,
+			PayeeType:                 c.Type,
+			PayeeID:                   c.Account,
+			PayeeName:                 c.Name,
+		}, appKey)
+	}
+	if err != nil {
+		c.Error = err.Error()
+		return
+	}
+	c.Applied = true
+}
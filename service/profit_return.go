@@ -0,0 +1,310 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/wneverfade/wechatpay-b2b/model"
+	"github.com/wneverfade/wechatpay-b2b/outno"
+	"github.com/wneverfade/wechatpay-b2b/types"
+)
+
+// ErrProfitReturnIncomplete 分账回退未全部成功，按 ProfitReturnRequireAll 策略未发起退款。
+var ErrProfitReturnIncomplete = errors.New("profit sharing return is incomplete")
+
+// ProfitReturnPolicy 决定分账回退结果对退款的影响。
+type ProfitReturnPolicy string
+
+const (
+	// ProfitReturnRequireAll 所有分账回退成功后才发起退款（默认）
+	ProfitReturnRequireAll ProfitReturnPolicy = "REQUIRE_ALL"
+	// ProfitReturnBestEffort 发起分账回退后无论结果如何都发起退款
+	ProfitReturnBestEffort ProfitReturnPolicy = "BEST_EFFORT"
+)
+
+// ProfitReturnItem 表示从单个分账接收方回退的金额及结果。
+type ProfitReturnItem struct {
+	PayeeType   model.ProfitReceiverType
+	PayeeID     string
+	OutReturnNo string // 首次由 outno.Derive(out_refund_no, "P", 接收方序号) 派生，失败重发时再以 "R" 与重发次数派生
+	Amount      model.Money
+	Attempts    int                      // 已使用的 out_return_no 个数
+	OrderStatus model.ProfitReturnStatus // QueryProfitSharingReturn 返回的 order_status，0 表示尚未查询到
+	Err         error
+}
+
+// Succeeded 判断分账回退是否已完成。
+func (i ProfitReturnItem) Succeeded() bool {
+	return i.OrderStatus == model.ProfitReturnSuccess
+}
+
+// ProfitReturnResult 表示一笔退款对应的分账回退及退款结果。
+type ProfitReturnResult struct {
+	Mchid       string
+	OutTradeNo  string
+	OutRefundNo string
+	Items       []ProfitReturnItem
+	Refund      *types.RefundResponse // 退款结果，未发起退款时为 nil
+	RefundErr   error
+}
+
+// Err 汇总分账回退与退款错误，全部成功时返回 nil。
+func (r *ProfitReturnResult) Err() error {
+	var errs []error
+	for _, item := range r.Items {
+		switch {
+		case item.Err != nil:
+			errs = append(errs, fmt.Errorf("payee %s: %w", item.PayeeID, item.Err))
+		case !item.Succeeded():
+			errs = append(errs, fmt.Errorf("payee %s: order_status %v", item.PayeeID, item.OrderStatus))
+		}
+	}
+	if r.RefundErr != nil {
+		errs = append(errs, r.RefundErr)
+	}
+	return errors.Join(errs...)
+}
+
+// ProfitReturnerOptions 分账回退参数，零值使用默认配置。
+type ProfitReturnerOptions struct {
+	Policy      ProfitReturnPolicy // 默认 ProfitReturnRequireAll
+	MaxAttempts int                // 每个接收方回退失败后最多使用的 out_return_no 个数（含首次），默认 3
+	MaxPolls    int                // 每个接收方最多查询回退结果的次数，默认 10
+	Wait        WaitOptions        // 查询回退结果的轮询间隔
+}
+
+// ProfitReturner 在订单退款时按原分账比例从各接收方回退分账。
+// 原分账结果来自 ProfitOrchestrator 保存的进度，仅分账成功的接收方参与回退。
+type ProfitReturner struct {
+	profit   ProfitService
+	refunder Refunder
+	progress ProfitProgressStore
+	appKeys  AppKeyResolver
+	opts     ProfitReturnerOptions
+}
+
+// NewProfitReturner 创建分账回退器，refunder 可传入 OrderService 或 RefundManager，仅处理退款通知时可为 nil。
+func NewProfitReturner(profit ProfitService, refunder Refunder, progress ProfitProgressStore, appKeys AppKeyResolver, opts ProfitReturnerOptions) *ProfitReturner {
+	if opts.Policy == "" {
+		opts.Policy = ProfitReturnRequireAll
+	}
+	if opts.MaxAttempts <= 0 {
+		opts.MaxAttempts = defaultProfitMaxAttempts
+	}
+	if opts.MaxPolls <= 0 {
+		opts.MaxPolls = defaultProfitMaxPolls
+	}
+	return &ProfitReturner{profit: profit, refunder: refunder, progress: progress, appKeys: appKeys, opts: opts}
+}
+
+// Allocate 计算各接收方应回退的金额，不发起请求。
+// 回退总额 = floor(refundAmount × 已分账总额 / orderAmount)，再按各接收方分账金额以最大余数法拆分；回退金额为 0 的接收方不会出现在结果中。
+func (r *ProfitReturner) Allocate(ctx context.Context, mchid, outTradeNo, outRefundNo string, refundAmount, orderAmount model.Money) ([]ProfitReturnItem, error) {
+	if r.progress == nil {
+		return nil, errors.New("profit returner is not initialized")
+	}
+	if mchid == "" {
+		return nil, errors.New("mchid is required")
+	}
+	if outTradeNo == "" {
+		return nil, errors.New("out_trade_no is required")
+	}
+	if outRefundNo == "" {
+		return nil, errors.New("out_refund_no is required")
+	}
+	if orderAmount <= 0 {
+		return nil, errors.New("order_amount must be positive")
+	}
+	if refundAmount <= 0 || refundAmount > orderAmount {
+		return nil, fmt.Errorf("refund_amount must be between 1 and %d", orderAmount)
+	}
+
+	progress, err := r.progress.Load(ctx, mchid, outTradeNo)
+	if err != nil {
+		return nil, err
+	}
+	if progress == nil {
+		return nil, nil
+	}
+	indexes := make([]int, 0, len(progress.Legs))
+	shared := make([]model.Money, 0, len(progress.Legs))
+	for i, leg := range progress.Legs {
+		if leg.State == ProfitLegSucceeded {
+			indexes = append(indexes, i)
+			shared = append(shared, leg.Amount)
+		}
+	}
+	total, err := model.SumMoney(shared...)
+	if err != nil {
+		return nil, err
+	}
+	if total == 0 {
+		return nil, nil
+	}
+
+	returnTotal := new(big.Int).Mul(big.NewInt(int64(refundAmount)), big.NewInt(int64(total)))
+	returnTotal.Quo(returnTotal, big.NewInt(int64(orderAmount)))
+	amounts, err := allocateProportional(model.Money(returnTotal.Int64()), shared)
+	if err != nil {
+		return nil, err
+	}
+
+	var items []ProfitReturnItem
+	for j, i := range indexes {
+		if amounts[j] == 0 {
+			continue
+		}
+		outReturnNo, err := outno.Derive(outRefundNo, "P", i+1)
+		if err != nil {
+			return nil, fmt.Errorf("out_return_no: %w", err)
+		}
+		leg := progress.Legs[i]
+		items = append(items, ProfitReturnItem{
+			PayeeType:   leg.ReceiverType,
+			PayeeID:     leg.ReceiverAccount,
+			OutReturnNo: outReturnNo,
+			Amount:      amounts[j],
+		})
+	}
+	return items, nil
+}
+
+// Refund 先按原分账比例回退分账，再按 Policy 决定是否发起退款。
+// ProfitReturnRequireAll 策略下有回退未成功时不发起退款，返回 ErrProfitReturnIncomplete；使用相同的 out_refund_no 重试即可继续，
+// 已失败的回退会以新派生的 out_return_no 重新发起。单个接收方失败达到 MaxAttempts 次后不再重发，需人工处理。
+func (r *ProfitReturner) Refund(ctx context.Context, req types.RefundRequest, orderAmount model.Money, appKey string) (*ProfitReturnResult, error) {
+	if r.refunder == nil {
+		return nil, errors.New("refunder is nil")
+	}
+	result, err := r.returnProfit(ctx, req.Mchid, req.OutTradeNo, req.OutRefundNo, req.RefundAmount, orderAmount, appKey)
+	if err != nil {
+		return result, err
+	}
+	if r.opts.Policy == ProfitReturnRequireAll {
+		if err := result.Err(); err != nil {
+			return result, errors.Join(ErrProfitReturnIncomplete, err)
+		}
+	}
+
+	result.Refund, result.RefundErr = r.refunder.CreateRefund(ctx, req, appKey)
+	if result.RefundErr != nil {
+		result.Refund = nil
+	}
+	return result, result.Err()
+}
+
+// HandleRefundNotify 在收到退款成功通知后回退分账，用于未经 Refund 发起的退款。其它退款状态会被忽略。
+func (r *ProfitReturner) HandleRefundNotify(ctx context.Context, n *model.RefundNotify) (*ProfitReturnResult, error) {
+	if n == nil {
+		return nil, errors.New("notify is nil")
+	}
+	if n.RefundStatus != model.RefundSuccess {
+		return nil, nil
+	}
+	if r.appKeys == nil {
+		return nil, errors.New("profit returner is not initialized")
+	}
+	appKey, err := r.appKeys.AppKey(ctx, n.Mchid)
+	if err != nil {
+		return nil, err
+	}
+	result, err := r.returnProfit(ctx, n.Mchid, n.OutTradeNo, n.OutRefundNo, n.RefundAmount, n.OrderAmount, appKey)
+	if err != nil {
+		return result, err
+	}
+	return result, result.Err()
+}
+
+// returnProfit 发起并跟踪各接收方的分账回退。
+func (r *ProfitReturner) returnProfit(ctx context.Context, mchid, outTradeNo, outRefundNo string, refundAmount, orderAmount model.Money, appKey string) (*ProfitReturnResult, error) {
+	if r.profit == nil {
+		return nil, errors.New("profit returner is not initialized")
+	}
+	items, err := r.Allocate(ctx, mchid, outTradeNo, outRefundNo, refundAmount, orderAmount)
+	if err != nil {
+		return nil, err
+	}
+	result := &ProfitReturnResult{Mchid: mchid, OutTradeNo: outTradeNo, OutRefundNo: outRefundNo, Items: items}
+	for i := range result.Items {
+		if err := r.returnItem(ctx, result, &result.Items[i], appKey); err != nil {
+			return result, err
+		}
+	}
+	return result, nil
+}
+
+// returnItem 依次使用派生的 out_return_no 回退单个接收方的分账，回退失败（终态）时换用下一个单号重发，仅在 ctx 结束时返回错误。
+// 每次都从首个单号开始查询，已失败的单号会被跳过，因此重试时不会重复回退。
+func (r *ProfitReturner) returnItem(ctx context.Context, result *ProfitReturnResult, item *ProfitReturnItem, appKey string) error {
+	base := item.OutReturnNo
+	for attempt := 1; ; attempt++ {
+		if attempt > 1 {
+			outReturnNo, err := outno.Derive(base, "R", attempt-1)
+			if err != nil {
+				item.Err = fmt.Errorf("out_return_no: %w", err)
+				return nil
+			}
+			item.OutReturnNo, item.OrderStatus = outReturnNo, 0
+		}
+		item.Attempts = attempt
+		if err := r.attemptReturn(ctx, result, item, appKey); err != nil {
+			return err
+		}
+		if item.OrderStatus != model.ProfitReturnFailed || attempt >= r.opts.MaxAttempts {
+			return nil
+		}
+	}
+}
+
+// attemptReturn 使用 item.OutReturnNo 发起分账回退并轮询结果。
+func (r *ProfitReturner) attemptReturn(ctx context.Context, result *ProfitReturnResult, item *ProfitReturnItem, appKey string) error {
+	// 重试时先查询，已受理的回退不再重复发起。
+	r.query(ctx, result, item, appKey)
+	if item.OrderStatus == 0 {
+		_, err := r.profit.ProfitSharingReturn(ctx, types.ProfitSharingReturnRequest{
+			Mchid:        result.Mchid,
+			OutTradeNo:   result.OutTradeNo,
+			OutReturnNo:  item.OutReturnNo,
+			PayeeType:    item.PayeeType,
+			PayeeID:      item.PayeeID,
+			RefundAmount: item.Amount,
+		}, appKey)
+		if err != nil {
+			item.Err = err
+			return nil
+		}
+		item.OrderStatus, item.Err = model.ProfitReturnProcessing, nil
+	}
+
+	b := newBackoff(r.opts.Wait)
+	for polls := 0; !item.OrderStatus.IsTerminal() && polls < r.opts.MaxPolls; polls++ {
+		if err := b.wait(ctx); err != nil {
+			return err
+		}
+		r.query(ctx, result, item, appKey)
+	}
+	return nil
+}
+
+// query 查询分账回退结果，查询失败时保留原状态。
+func (r *ProfitReturner) query(ctx context.Context, result *ProfitReturnResult, item *ProfitReturnItem, appKey string) {
+	resp, err := r.profit.QueryProfitSharingReturn(ctx, types.QueryProfitSharingReturnRequest{
+		OutTradeNo:  result.OutTradeNo,
+		OutRefundNo: item.OutReturnNo,
+		Mchid:       result.Mchid,
+		PayeeType:   item.PayeeType,
+		PayeeID:     item.PayeeID,
+	}, appKey)
+	if err != nil {
+		item.Err = err
+		return
+	}
+	item.Err = nil
+	if !resp.OrderStatus.IsKnown() {
+		item.Err = fmt.Errorf("%w: order_status %v", model.ErrUnknownStatus, resp.OrderStatus)
+		return
+	}
+	item.OrderStatus = resp.OrderStatus
+}
@@ -0,0 +1,374 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/wneverfade/wechatpay-b2b/model"
+	"github.com/wneverfade/wechatpay-b2b/types"
+)
+
+const (
+	defaultProfitDelay         = time.Hour
+	defaultProfitWindow        = 30 * 24 * time.Hour
+	defaultProfitAlertBefore   = 24 * time.Hour
+	defaultProfitRetryInterval = 10 * time.Minute
+	defaultProfitScanInterval  = time.Minute
+)
+
+// ProfitJob 表示等待执行的分账任务。
+type ProfitJob struct {
+	Mchid       string      `json:"mchid"`
+	OutTradeNo  string      `json:"out_trade_no"`
+	OrderAmount model.Money `json:"order_amount"`
+	PlatformFee model.Money `json:"platform_fee"` // 技术服务费，来自支付通知时为 0
+	PaidAt      time.Time   `json:"paid_at"`
+	DueAt       time.Time   `json:"due_at"`               // 下次执行时间
+	Deadline    time.Time   `json:"deadline"`             // 分账窗口截止时间
+	Attempts    int         `json:"attempts"`             // 已执行次数
+	LastError   string      `json:"last_error,omitempty"` // 最近一次错误
+	Alerted     bool        `json:"alerted"`              // 已发出临近截止告警
+}
+
+// ProfitJobQueue 持久化分账任务，保证进程重启后不会丢失。
+type ProfitJobQueue interface {
+	// Save 新增或更新分账任务（以 mchid + out_trade_no 为键）。
+	Save(ctx context.Context, job ProfitJob) error
+	// Get 读取分账任务，不存在时返回 nil, nil。
+	Get(ctx context.Context, mchid, outTradeNo string) (*ProfitJob, error)
+	// Delete 删除分账任务，不存在时返回 nil。
+	Delete(ctx context.Context, mchid, outTradeNo string) error
+	// Due 返回 DueAt 不晚于 now 的分账任务。
+	Due(ctx context.Context, now time.Time) ([]ProfitJob, error)
+}
+
+// ProfitJobOutcome 表示一次分账任务处理的结果。
+type ProfitJobOutcome string
+
+const (
+	// ProfitJobFinished 分账已完成并完结
+	ProfitJobFinished ProfitJobOutcome = "FINISHED"
+	// ProfitJobRetry 未完成，等待下次重试
+	ProfitJobRetry ProfitJobOutcome = "RETRY"
+	// ProfitJobExpired 超过分账窗口仍未完成，已放弃
+	ProfitJobExpired ProfitJobOutcome = "EXPIRED"
+)
+
+// ProfitJobResult 表示单个分账任务的处理结果。
+type ProfitJobResult struct {
+	Job      ProfitJob
+	Outcome  ProfitJobOutcome
+	Progress *ProfitProgress // 分账进度，未开始执行时为 nil
+	Err      error
+}
+
+// ProfitSchedulerOptions 延迟分账参数，零值使用默认配置。
+type ProfitSchedulerOptions struct {
+	// Rules 返回订单的分账规则，必填。
+	Rules func(ctx context.Context, job ProfitJob) ([]ProfitRule, error)
+	// NeedProfitSharing 判断订单下单时是否设置了 need_profit_sharing，必填。
+	// 支付通知与 GetOrder 结果均不含该字段，需由业务侧根据下单记录判断；未设置分账的订单调用分账接口会失败。
+	NeedProfitSharing func(ctx context.Context, mchid, outTradeNo string) (bool, error)
+
+	Delay         time.Duration         // 支付后延迟多久分账，默认 1h
+	Window        time.Duration         // 支付后允许分账的时长，默认 30 天，以微信实际规则为准
+	AlertBefore   time.Duration         // 距分账截止多久仍未完成时告警，默认 24h
+	RetryInterval time.Duration         // 未完成任务的重试间隔，默认 10m
+	Interval      time.Duration         // Run 的扫描间隔，默认 1m
+	OnAlert       func(ProfitJobResult) // 临近截止仍未完成时回调，每个任务只回调一次
+	OnResult      func(ProfitJobResult) // 每个任务处理完成后回调
+	OnError       func(error)           // Run 中读写队列失败时回调
+	Now           func() time.Time      // 当前时间，默认 time.Now
+}
+
+// ProfitScheduler 在订单支付后延迟执行分账：按规则规划金额，交由 ProfitOrchestrator 执行并完结，
+// 未完成的任务按 RetryInterval 重试直到分账窗口截止。
+type ProfitScheduler struct {
+	profit       ProfitService
+	orchestrator *ProfitOrchestrator
+	queue        ProfitJobQueue
+	appKeys      AppKeyResolver
+	opts         ProfitSchedulerOptions
+}
+
+// NewProfitScheduler 创建延迟分账调度器。
+func NewProfitScheduler(profit ProfitService, orchestrator *ProfitOrchestrator, queue ProfitJobQueue, appKeys AppKeyResolver, opts ProfitSchedulerOptions) *ProfitScheduler {
+	if opts.Delay <= 0 {
+		opts.Delay = defaultProfitDelay
+	}
+	if opts.Window <= 0 {
+		opts.Window = defaultProfitWindow
+	}
+	if opts.AlertBefore <= 0 {
+		opts.AlertBefore = defaultProfitAlertBefore
+	}
+	if opts.RetryInterval <= 0 {
+		opts.RetryInterval = defaultProfitRetryInterval
+	}
+	if opts.Interval <= 0 {
+		opts.Interval = defaultProfitScanInterval
+	}
+	if opts.Now == nil {
+		opts.Now = time.Now
+	}
+	return &ProfitScheduler{profit: profit, orchestrator: orchestrator, queue: queue, appKeys: appKeys, opts: opts}
+}
+
+// EnqueuePayment 根据支付成功通知登记分账任务，未支付或无需分账的订单会被忽略。
+func (s *ProfitScheduler) EnqueuePayment(ctx context.Context, n *model.PaymentNotify) error {
+	if n == nil {
+		return errors.New("notify is nil")
+	}
+	if n.PayStatus != model.PayStatusSuccess {
+		return nil
+	}
+	return s.enqueue(ctx, ProfitJob{
+		Mchid:       n.Mchid,
+		OutTradeNo:  n.OutTradeNo,
+		OrderAmount: n.Amount.OrderAmount,
+		PaidAt:      n.PayTime.Time,
+	})
+}
+
+// EnqueueOrder 根据 GetOrder 的查询结果登记分账任务，未支付或无需分账的订单会被忽略。
+func (s *ProfitScheduler) EnqueueOrder(ctx context.Context, order *types.GetOrderResponse) error {
+	if order == nil {
+		return errors.New("order is nil")
+	}
+	if order.PayStatus != model.PayStatusSuccess {
+		return nil
+	}
+	return s.enqueue(ctx, ProfitJob{
+		Mchid:       order.Mchid,
+		OutTradeNo:  order.OutTradeNo,
+		OrderAmount: order.Amount.OrderAmount,
+		PlatformFee: order.PlatformProfitFee,
+		PaidAt:      order.PayTime.Time,
+	})
+}
+
+// enqueue 计算执行时间并保存任务，已登记的订单不会重复登记。
+func (s *ProfitScheduler) enqueue(ctx context.Context, job ProfitJob) error {
+	if s.queue == nil {
+		return errors.New("profit scheduler is not initialized")
+	}
+	if s.opts.NeedProfitSharing == nil {
+		return errors.New("NeedProfitSharing is required")
+	}
+	if job.Mchid == "" {
+		return errors.New("mchid is required")
+	}
+	if job.OutTradeNo == "" {
+		return errors.New("out_trade_no is required")
+	}
+	if job.OrderAmount <= 0 {
+		return errors.New("order_amount must be positive")
+	}
+	need, err := s.opts.NeedProfitSharing(ctx, job.Mchid, job.OutTradeNo)
+	if err != nil {
+		return err
+	}
+	if !need {
+		return nil
+	}
+	existing, err := s.queue.Get(ctx, job.Mchid, job.OutTradeNo)
+	if err != nil {
+		return err
+	}
+	if existing != nil {
+		return nil
+	}
+
+	if job.PaidAt.IsZero() {
+		job.PaidAt = s.opts.Now()
+	}
+	job.DueAt = job.PaidAt.Add(s.opts.Delay)
+	job.Deadline = job.PaidAt.Add(s.opts.Window)
+	return s.queue.Save(ctx, job)
+}
+
+// Run 按 Interval 周期性处理到期任务，直到 ctx 结束。
+func (s *ProfitScheduler) Run(ctx context.Context) error {
+	ticker := time.NewTicker(s.opts.Interval)
+	defer ticker.Stop()
+	for {
+		if _, err := s.RunOnce(ctx); err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			if s.opts.OnError != nil {
+				s.opts.OnError(err)
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunOnce 处理一次所有到期任务。
+func (s *ProfitScheduler) RunOnce(ctx context.Context) ([]ProfitJobResult, error) {
+	if s.profit == nil || s.orchestrator == nil || s.queue == nil || s.appKeys == nil || s.opts.Rules == nil {
+		return nil, errors.New("profit scheduler is not initialized")
+	}
+	jobs, err := s.queue.Due(ctx, s.opts.Now())
+	if err != nil {
+		return nil, err
+	}
+	results := make([]ProfitJobResult, 0, len(jobs))
+	for _, job := range jobs {
+		if err := ctx.Err(); err != nil {
+			return results, err
+		}
+		res := s.process(ctx, job)
+		if err := s.finish(ctx, &res); err != nil {
+			return results, err
+		}
+		if s.opts.OnResult != nil {
+			s.opts.OnResult(res)
+		}
+		results = append(results, res)
+	}
+	return results, nil
+}
+
+// process 执行单个分账任务：已有进度时继续执行，否则按规则规划后开始执行。
+func (s *ProfitScheduler) process(ctx context.Context, job ProfitJob) ProfitJobResult {
+	job.Attempts++
+	res := ProfitJobResult{Job: job, Outcome: ProfitJobRetry}
+
+	res.Progress, res.Err = s.orchestrator.Resume(ctx, job.Mchid, job.OutTradeNo)
+	if errors.Is(res.Err, ErrProfitProgressNotFound) {
+		res.Progress, res.Err = s.start(ctx, job)
+	}
+	if res.Err == nil && (res.Progress == nil || res.Progress.Finished) {
+		res.Outcome = ProfitJobFinished
+	}
+	return res
+}
+
+// start 规划分账金额并交由编排器执行。剩余可分账金额为 0 时视为已完结；没有需要分账的接收方时直接完结分账。
+func (s *ProfitScheduler) start(ctx context.Context, job ProfitJob) (*ProfitProgress, error) {
+	rules, err := s.opts.Rules(ctx, job)
+	if err != nil {
+		return nil, err
+	}
+	appKey, err := s.appKeys.AppKey(ctx, job.Mchid)
+	if err != nil {
+		return nil, err
+	}
+	remainReq := types.QueryProfitSharingRemainAmtRequest{Mchid: job.Mchid, OutTradeNo: job.OutTradeNo}
+	remain, err := s.profit.QueryProfitSharingRemainAmt(ctx, remainReq, appKey)
+	if err != nil {
+		return nil, err
+	}
+	if remain.RemainAmount == 0 {
+		return nil, nil
+	}
+	plan, err := PlanProfitSharing(ProfitPlanInput{
+		OrderAmount:  job.OrderAmount,
+		PlatformFee:  job.PlatformFee,
+		RemainAmount: &remain.RemainAmount,
+	}, rules)
+	if err != nil {
+		return nil, err
+	}
+	if len(plan.Legs) == 0 {
+		resp, err := s.profit.ProfitSharingFinish(ctx, types.ProfitSharingFinishRequest{
+			Mchid:      job.Mchid,
+			OutTradeNo: job.OutTradeNo,
+		}, appKey)
+		if err != nil && resp != nil {
+			// 微信返回错误码时可能已完结过，剩余可分账金额为 0 即视为成功。
+			if after, qerr := s.profit.QueryProfitSharingRemainAmt(ctx, remainReq, appKey); qerr == nil && after.RemainAmount == 0 {
+				return nil, nil
+			}
+		}
+		return nil, err
+	}
+	return s.orchestrator.Execute(ctx, job.Mchid, job.OutTradeNo, plan.Legs)
+}
+
+// finish 根据处理结果更新队列并在临近截止时告警。
+func (s *ProfitScheduler) finish(ctx context.Context, res *ProfitJobResult) error {
+	if res.Outcome == ProfitJobFinished {
+		return s.queue.Delete(ctx, res.Job.Mchid, res.Job.OutTradeNo)
+	}
+
+	now := s.opts.Now()
+	if res.Err != nil {
+		res.Job.LastError = res.Err.Error()
+	}
+	if !res.Job.Alerted && !now.Before(res.Job.Deadline.Add(-s.opts.AlertBefore)) {
+		res.Job.Alerted = true
+		if s.opts.OnAlert != nil {
+			s.opts.OnAlert(*res)
+		}
+	}
+	if !now.Before(res.Job.Deadline) {
+		res.Outcome = ProfitJobExpired
+		return s.queue.Delete(ctx, res.Job.Mchid, res.Job.OutTradeNo)
+	}
+	res.Job.DueAt = now.Add(s.opts.RetryInterval)
+	if res.Job.DueAt.After(res.Job.Deadline) {
+		res.Job.DueAt = res.Job.Deadline
+	}
+	return s.queue.Save(ctx, res.Job)
+}
+
+// MemoryProfitJobQueue 是 ProfitJobQueue 的内存实现，进程重启后数据会丢失，适用于测试或单机场景。
+type MemoryProfitJobQueue struct {
+	mu   sync.Mutex
+	jobs map[string]ProfitJob
+}
+
+// NewMemoryProfitJobQueue 创建内存分账任务队列。
+func NewMemoryProfitJobQueue() *MemoryProfitJobQueue {
+	return &MemoryProfitJobQueue{jobs: make(map[string]ProfitJob)}
+}
+
+// Save 新增或更新分账任务。
+func (m *MemoryProfitJobQueue) Save(ctx context.Context, job ProfitJob) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.jobs[job.Mchid+"/"+job.OutTradeNo] = job
+	return nil
+}
+
+// Get 读取分账任务。
+func (m *MemoryProfitJobQueue) Get(ctx context.Context, mchid, outTradeNo string) (*ProfitJob, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	job, ok := m.jobs[mchid+"/"+outTradeNo]
+	if !ok {
+		return nil, nil
+	}
+	return &job, nil
+}
+
+// Delete 删除分账任务。
+func (m *MemoryProfitJobQueue) Delete(ctx context.Context, mchid, outTradeNo string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.jobs, mchid+"/"+outTradeNo)
+	return nil
+}
+
+// Due 返回已到期的分账任务，按 DueAt 升序。
+func (m *MemoryProfitJobQueue) Due(ctx context.Context, now time.Time) ([]ProfitJob, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var out []ProfitJob
+	for _, job := range m.jobs {
+		if !job.DueAt.After(now) {
+			out = append(out, job)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].DueAt.Before(out[j].DueAt) })
+	return out, nil
+}
@@ -0,0 +1,279 @@
+package service
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/wneverfade/wechatpay-b2b/model"
+	"github.com/wneverfade/wechatpay-b2b/types"
+)
+
+// LocalOrder 表示业务侧记录的订单。
+type LocalOrder struct {
+	Mchid      string
+	OutTradeNo string
+	Status     model.PayStatus
+	Amount     model.Money // 订单金额
+	Refunds    []LocalRefund
+}
+
+// LocalRefund 表示业务侧记录的退款。
+type LocalRefund struct {
+	OutRefundNo string
+	Amount      model.Money
+	Status      model.RefundStatus
+}
+
+// LocalOrderIterator 逐条返回业务侧订单，遍历结束时返回 io.EOF。
+type LocalOrderIterator interface {
+	Next(ctx context.Context) (*LocalOrder, error)
+}
+
+// DiscrepancyType 表示对账差异类型。
+type DiscrepancyType string
+
+const (
+	// DiscrepancyPaidRemotely 微信侧已支付，本地未支付
+	DiscrepancyPaidRemotely DiscrepancyType = "PAID_REMOTELY_PENDING_LOCALLY"
+	// DiscrepancyAmountMismatch 金额不一致
+	DiscrepancyAmountMismatch DiscrepancyType = "AMOUNT_MISMATCH"
+	// DiscrepancyRefundedRemotely 微信侧已退款，本地未记录退款
+	DiscrepancyRefundedRemotely DiscrepancyType = "REFUNDED_REMOTELY_NOT_LOCALLY"
+	// DiscrepancyMissingRemotely 微信侧查询不到
+	DiscrepancyMissingRemotely DiscrepancyType = "MISSING_REMOTELY"
+	// DiscrepancyStatusMismatch 其它状态不一致
+	DiscrepancyStatusMismatch DiscrepancyType = "STATUS_MISMATCH"
+	// DiscrepancyQueryFailed 查询失败，无法判断
+	DiscrepancyQueryFailed DiscrepancyType = "QUERY_FAILED"
+)
+
+// Discrepancy 表示一条对账差异。
+type Discrepancy struct {
+	Type         DiscrepancyType `json:"type"`
+	Mchid        string          `json:"mchid"`
+	OutTradeNo   string          `json:"out_trade_no"`
+	OutRefundNo  string          `json:"out_refund_no,omitempty"`
+	LocalStatus  string          `json:"local_status,omitempty"`
+	RemoteStatus string          `json:"remote_status,omitempty"`
+	LocalAmount  model.Money     `json:"local_amount"`
+	RemoteAmount model.Money     `json:"remote_amount"`
+	Detail       string          `json:"detail,omitempty"`
+	Fixed        bool            `json:"fixed"`
+	FixError     string          `json:"fix_error,omitempty"`
+
+	Local        *LocalOrder              `json:"-"`
+	RemoteOrder  *types.GetOrderResponse  `json:"-"`
+	RemoteRefund *types.GetRefundResponse `json:"-"`
+}
+
+// ReconcileReport 表示一次对账的结果。
+type ReconcileReport struct {
+	StartedAt     time.Time     `json:"started_at"`
+	FinishedAt    time.Time     `json:"finished_at"`
+	Checked       int           `json:"checked"` // 已核对的订单数
+	Matched       int           `json:"matched"` // 无差异的订单数
+	Discrepancies []Discrepancy `json:"discrepancies"`
+}
+
+// WriteJSON 以 JSON 格式输出对账报告。
+func (r *ReconcileReport) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r)
+}
+
+// WriteCSV 以 CSV 格式输出差异明细，每条差异一行。
+func (r *ReconcileReport) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	header := []string{"type", "mchid", "out_trade_no", "out_refund_no", "local_status", "remote_status", "local_amount", "remote_amount", "detail", "fixed", "fix_error"}
+	if err := cw.Write(header); err != nil {
+		return err
+	}
+	for _, d := range r.Discrepancies {
+		row := []string{
+			string(d.Type), d.Mchid, d.OutTradeNo, d.OutRefundNo, d.LocalStatus, d.RemoteStatus,
+			strconv.FormatInt(d.LocalAmount.Fen(), 10), strconv.FormatInt(d.RemoteAmount.Fen(), 10),
+			d.Detail, strconv.FormatBool(d.Fixed), d.FixError,
+		}
+		if err := cw.Write(row); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// ReconcilerOptions 对账参数。
+type ReconcilerOptions struct {
+	// AutoFix 对每条差异调用（DiscrepancyQueryFailed 除外），返回 nil 表示已修复。为空时只生成报告。
+	AutoFix func(ctx context.Context, d *Discrepancy) error
+	// OrderNotFoundCodes、RefundNotFoundCodes 为 GetOrder、GetRefund 表示订单、退款单不存在的 errcode，
+	// 仅这些 errcode 记为 DiscrepancyMissingRemotely；其它 errcode（限频、签名错误、系统错误等）及未设置时均记为 DiscrepancyQueryFailed。
+	OrderNotFoundCodes  []int
+	RefundNotFoundCodes []int
+	// Now 当前时间，默认 time.Now。
+	Now func() time.Time
+}
+
+// Reconciler 以微信侧订单与退款状态为准，核对业务侧订单记录。
+type Reconciler struct {
+	orders  OrderService
+	appKeys AppKeyResolver
+	opts    ReconcilerOptions
+}
+
+// NewReconciler 创建对账引擎。
+func NewReconciler(orders OrderService, appKeys AppKeyResolver, opts ReconcilerOptions) *Reconciler {
+	if opts.Now == nil {
+		opts.Now = time.Now
+	}
+	return &Reconciler{orders: orders, appKeys: appKeys, opts: opts}
+}
+
+// Reconcile 遍历业务侧订单并逐条核对，返回对账报告。
+// 单个订单查询失败记为 DiscrepancyQueryFailed，不会中断对账；迭代器出错或 ctx 结束时返回已生成的报告及错误。
+func (r *Reconciler) Reconcile(ctx context.Context, it LocalOrderIterator) (*ReconcileReport, error) {
+	if r.orders == nil || r.appKeys == nil {
+		return nil, errors.New("reconciler is not initialized")
+	}
+	report := &ReconcileReport{StartedAt: r.opts.Now()}
+	defer func() { report.FinishedAt = r.opts.Now() }()
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return report, err
+		}
+		local, err := it.Next(ctx)
+		if errors.Is(err, io.EOF) {
+			return report, nil
+		}
+		if err != nil {
+			return report, err
+		}
+
+		found := r.check(ctx, local)
+		report.Checked++
+		if len(found) == 0 {
+			report.Matched++
+			continue
+		}
+		for i := range found {
+			if r.opts.AutoFix != nil && found[i].Type != DiscrepancyQueryFailed {
+				if err := r.opts.AutoFix(ctx, &found[i]); err != nil {
+					found[i].FixError = err.Error()
+				} else {
+					found[i].Fixed = true
+				}
+			}
+		}
+		report.Discrepancies = append(report.Discrepancies, found...)
+	}
+}
+
+// check 核对单个订单及其退款。
+func (r *Reconciler) check(ctx context.Context, local *LocalOrder) []Discrepancy {
+	base := Discrepancy{
+		Mchid:       local.Mchid,
+		OutTradeNo:  local.OutTradeNo,
+		LocalStatus: string(local.Status),
+		LocalAmount: local.Amount,
+		Local:       local,
+	}
+
+	appKey, err := r.appKeys.AppKey(ctx, local.Mchid)
+	if err != nil {
+		d := base
+		d.Type, d.Detail = DiscrepancyQueryFailed, err.Error()
+		return []Discrepancy{d}
+	}
+
+	remote, err := r.orders.GetOrder(ctx, types.GetOrderRequest{Mchid: local.Mchid, OutTradeNo: local.OutTradeNo}, appKey)
+	if err != nil {
+		d := base
+		d.Type, d.Detail = DiscrepancyQueryFailed, err.Error()
+		if remote != nil && slices.Contains(r.opts.OrderNotFoundCodes, remote.ErrCode) {
+			d.Type = DiscrepancyMissingRemotely
+		}
+		return []Discrepancy{d}
+	}
+	base.RemoteStatus = string(remote.PayStatus)
+	base.RemoteAmount = remote.Amount.OrderAmount
+	base.RemoteOrder = remote
+
+	var out []Discrepancy
+	add := func(t DiscrepancyType, detail string) {
+		d := base
+		d.Type, d.Detail = t, detail
+		out = append(out, d)
+	}
+
+	if local.Amount != remote.Amount.OrderAmount {
+		add(DiscrepancyAmountMismatch, fmt.Sprintf("order_amount local=%d remote=%d", local.Amount, remote.Amount.OrderAmount))
+	}
+
+	refundedRemotely := remote.PayStatus == model.PayStatusRefunding || remote.PayStatus == model.PayStatusRefunded
+	refundedLocally := local.Status == model.PayStatusRefunding || local.Status == model.PayStatusRefunded || len(local.Refunds) > 0
+	switch {
+	case remote.PayStatus == local.Status:
+	case remote.PayStatus.IsPaid() && !local.Status.IsPaid():
+		add(DiscrepancyPaidRemotely, "")
+	case refundedRemotely && !refundedLocally:
+		add(DiscrepancyRefundedRemotely, "")
+	case refundedRemotely && refundedLocally:
+		// 退款中/已退款之间的差异由退款明细核对。
+	default:
+		add(DiscrepancyStatusMismatch, "")
+	}
+
+	for _, refund := range local.Refunds {
+		out = append(out, r.checkRefund(ctx, base, refund, appKey)...)
+	}
+	return out
+}
+
+// checkRefund 核对单笔退款。
+func (r *Reconciler) checkRefund(ctx context.Context, base Discrepancy, local LocalRefund, appKey string) []Discrepancy {
+	base.OutRefundNo = local.OutRefundNo
+	base.LocalStatus = string(local.Status)
+	base.LocalAmount = local.Amount
+	base.RemoteStatus = ""
+	base.RemoteAmount = 0
+
+	remote, err := r.orders.GetRefund(ctx, types.GetRefundRequest{Mchid: base.Mchid, OutRefundNo: local.OutRefundNo}, appKey)
+	if err != nil {
+		d := base
+		d.Type, d.Detail = DiscrepancyQueryFailed, err.Error()
+		if remote != nil && slices.Contains(r.opts.RefundNotFoundCodes, remote.ErrCode) {
+			d.Type = DiscrepancyMissingRemotely
+		}
+		return []Discrepancy{d}
+	}
+	base.RemoteStatus = string(remote.RefundStatus)
+	base.RemoteAmount = remote.Amount.RefundAmount
+	base.RemoteRefund = remote
+
+	var out []Discrepancy
+	add := func(t DiscrepancyType, detail string) {
+		d := base
+		d.Type, d.Detail = t, detail
+		out = append(out, d)
+	}
+	if local.Amount != remote.Amount.RefundAmount {
+		add(DiscrepancyAmountMismatch, fmt.Sprintf("refund_amount local=%d remote=%d", local.Amount, remote.Amount.RefundAmount))
+	}
+	switch {
+	case remote.RefundStatus == local.Status:
+	case remote.RefundStatus == model.RefundSuccess:
+		add(DiscrepancyRefundedRemotely, "")
+	default:
+		add(DiscrepancyStatusMismatch, "")
+	}
+	return out
+}
@@ -0,0 +1,334 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/wneverfade/wechatpay-b2b/model"
+	"github.com/wneverfade/wechatpay-b2b/types"
+)
+
+var (
+	// ErrOverRefund 表示退款金额超过订单剩余可退金额。
+	ErrOverRefund = errors.New("refund amount exceeds refundable amount")
+	// ErrDuplicateOutRefundNo 表示 out_refund_no 已被使用。
+	ErrDuplicateOutRefundNo = errors.New("out_refund_no already exists")
+)
+
+// RefundRecord 表示退款台账中的一笔退款。
+type RefundRecord struct {
+	Mchid       string             `json:"mchid"`
+	OutTradeNo  string             `json:"out_trade_no"`
+	OutRefundNo string             `json:"out_refund_no"`
+	RefundID    string             `json:"refund_id,omitempty"`
+	Amount      model.Money        `json:"amount"`
+	Status      model.RefundStatus `json:"status"`
+	CreatedAt   time.Time          `json:"created_at"`
+	UpdatedAt   time.Time          `json:"updated_at"`
+}
+
+// RefundLedger 持久化退款记录，以 mchid + out_refund_no 为键。
+type RefundLedger interface {
+	// Get 查询退款记录，不存在时返回 nil, nil。
+	Get(ctx context.Context, mchid, outRefundNo string) (*RefundRecord, error)
+	// List 返回订单下的全部退款记录。
+	List(ctx context.Context, mchid, outTradeNo string) ([]RefundRecord, error)
+	// Save 新增或更新退款记录。
+	Save(ctx context.Context, rec RefundRecord) error
+	// Pending 返回创建时间早于 before 且未终态的退款记录。
+	Pending(ctx context.Context, before time.Time) ([]RefundRecord, error)
+}
+
+// RefundManagerOptions 退款管理器参数，零值使用默认配置。
+type RefundManagerOptions struct {
+	// RefundNotFoundCodes 为 GetRefund 表示退款单不存在的 errcode。CreateRefund 返回错误码后复查退款单，
+	// 仅这些 errcode 视为退款未受理并释放额度；其它 errcode（限频、系统繁忙等）及未设置时保留占用，待 Refresh 确认。
+	RefundNotFoundCodes []int
+	// Now 当前时间，默认 time.Now。
+	Now func() time.Time
+}
+
+// RefundManager 在发起退款前基于订单金额与退款台账校验剩余可退金额，防止超额退款。
+type RefundManager struct {
+	orders OrderService
+	ledger RefundLedger
+	opts   RefundManagerOptions
+	mu     sync.Mutex // 保证同一进程内「校验剩余金额 + 占用额度」的原子性
+}
+
+// NewRefundManager 创建退款管理器。
+func NewRefundManager(orders OrderService, ledger RefundLedger, opts RefundManagerOptions) *RefundManager {
+	if opts.Now == nil {
+		opts.Now = time.Now
+	}
+	return &RefundManager{orders: orders, ledger: ledger, opts: opts}
+}
+
+// Refundable 返回订单的剩余可退金额（订单金额减去台账中未失败的退款）以及查询到的订单。
+func (m *RefundManager) Refundable(ctx context.Context, req types.GetOrderRequest, appKey string) (model.Money, *types.GetOrderResponse, error) {
+	if m.orders == nil || m.ledger == nil {
+		return 0, nil, errors.New("refund manager is not initialized")
+	}
+	order, err := m.orders.GetOrder(ctx, req, appKey)
+	if err != nil {
+		return 0, order, err
+	}
+	remaining, err := m.remaining(ctx, req.Mchid, refundOutTradeNo(req.OutTradeNo, order), order.Amount.OrderAmount)
+	return remaining, order, err
+}
+
+// refundOutTradeNo 优先使用请求中的 out_trade_no，仅按 order_id 查询时使用查单结果中的 out_trade_no。
+func refundOutTradeNo(outTradeNo string, order *types.GetOrderResponse) string {
+	if outTradeNo != "" {
+		return outTradeNo
+	}
+	return order.OutTradeNo
+}
+
+// CreateRefund 校验剩余可退金额与 out_refund_no 唯一性后发起退款，并记录到台账。
+// 方法签名与 OrderService.CreateRefund 一致，可直接替换使用。
+func (m *RefundManager) CreateRefund(ctx context.Context, req types.RefundRequest, appKey string) (*types.RefundResponse, error) {
+	if req.Mchid == "" {
+		return nil, errors.New("mchid is required")
+	}
+	if req.OutTradeNo == "" && req.OrderID == "" {
+		return nil, errors.New("out_trade_no or order_id is required")
+	}
+	if req.OutRefundNo == "" {
+		return nil, errors.New("out_refund_no is required")
+	}
+	if req.RefundAmount <= 0 {
+		return nil, errors.New("refund_amount is required")
+	}
+
+	_, order, err := m.Refundable(ctx, types.GetOrderRequest{Mchid: req.Mchid, OutTradeNo: req.OutTradeNo, OrderID: req.OrderID}, appKey)
+	if err != nil {
+		return nil, err
+	}
+	if !order.PayStatus.CanRefund() {
+		return nil, fmt.Errorf("order pay_status %s is not refundable, want %s or %s", order.PayStatus, model.PayStatusSuccess, model.PayStatusRefunding)
+	}
+
+	rec, err := m.reserve(ctx, req, order)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := m.orders.CreateRefund(ctx, req, appKey)
+	if err != nil {
+		// 网络错误无法确认结果，保留占用，待 Refresh 确认；微信返回错误码时退款仍可能已受理，先查询退款单再决定是否释放额度。
+		if resp != nil && m.settleRejected(ctx, &rec, appKey) {
+			if saveErr := m.ledger.Save(ctx, rec); saveErr != nil {
+				return resp, errors.Join(err, saveErr)
+			}
+		}
+		return resp, err
+	}
+
+	rec.RefundID = resp.RefundID
+	rec.UpdatedAt = m.opts.Now()
+	if err := m.ledger.Save(ctx, rec); err != nil {
+		return resp, err
+	}
+	return resp, nil
+}
+
+// settleRejected 在 CreateRefund 返回错误码后查询退款单并更新记录，返回记录是否需要保存。
+// 查询到退款单时以查询结果为准；GetRefund 返回 RefundNotFoundCodes 中的错误码时视为未受理，标记为 REFUND_FAIL 释放额度；
+// 其它错误码与网络错误无法确认结果，保留占用。
+func (m *RefundManager) settleRejected(ctx context.Context, rec *RefundRecord, appKey string) bool {
+	refund, err := m.orders.GetRefund(ctx, types.GetRefundRequest{Mchid: rec.Mchid, OutRefundNo: rec.OutRefundNo}, appKey)
+	switch {
+	case err == nil:
+		if applyRefundStatus(rec, refund.RefundStatus) != nil {
+			// 查询结果比台账状态更旧，保留占用，待 Refresh 确认。
+			return false
+		}
+		if refund.RefundID != "" {
+			rec.RefundID = refund.RefundID
+		}
+	case refund != nil && slices.Contains(m.opts.RefundNotFoundCodes, refund.ErrCode):
+		rec.Status = model.RefundFail
+	default:
+		return false
+	}
+	rec.UpdatedAt = m.opts.Now()
+	return true
+}
+
+// Refresh 通过 GetRefund 更新订单下未终态的退款记录，返回更新后的全部记录。
+// 查询结果的状态不能由台账状态流转得到（如成功后又查到处理中）时不更新该记录，错误汇总后返回。
+func (m *RefundManager) Refresh(ctx context.Context, mchid, outTradeNo string, appKey string) ([]RefundRecord, error) {
+	if m.orders == nil || m.ledger == nil {
+		return nil, errors.New("refund manager is not initialized")
+	}
+	records, err := m.ledger.List(ctx, mchid, outTradeNo)
+	if err != nil {
+		return nil, err
+	}
+	var rejected []error
+	for i, rec := range records {
+		if rec.Status.IsTerminal() {
+			continue
+		}
+		resp, err := m.orders.GetRefund(ctx, types.GetRefundRequest{Mchid: rec.Mchid, OutRefundNo: rec.OutRefundNo}, appKey)
+		if err != nil {
+			return records, err
+		}
+		if err := applyRefundStatus(&rec, resp.RefundStatus); err != nil {
+			rejected = append(rejected, err)
+			continue
+		}
+		if resp.RefundID != "" {
+			rec.RefundID = resp.RefundID
+		}
+		rec.UpdatedAt = m.opts.Now()
+		if err := m.ledger.Save(ctx, rec); err != nil {
+			return records, err
+		}
+		records[i] = rec
+	}
+	return records, errors.Join(rejected...)
+}
+
+// applyRefundStatus 按 model.TransitionRefund 校验后更新记录状态，拒绝乱序的状态更新；未知状态照常记录，空状态忽略。
+func applyRefundStatus(rec *RefundRecord, to model.RefundStatus) error {
+	if to == "" {
+		return nil
+	}
+	if err := model.TransitionRefund(rec.Status, to); errors.Is(err, model.ErrInvalidTransition) {
+		return fmt.Errorf("out_refund_no %s: %w", rec.OutRefundNo, err)
+	}
+	rec.Status = to
+	return nil
+}
+
+// reserve 在台账中占用本次退款额度。
+func (m *RefundManager) reserve(ctx context.Context, req types.RefundRequest, order *types.GetOrderResponse) (RefundRecord, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	existing, err := m.ledger.Get(ctx, req.Mchid, req.OutRefundNo)
+	if err != nil {
+		return RefundRecord{}, err
+	}
+	if existing != nil {
+		return RefundRecord{}, fmt.Errorf("%w: %s", ErrDuplicateOutRefundNo, req.OutRefundNo)
+	}
+
+	outTradeNo := refundOutTradeNo(req.OutTradeNo, order)
+	if outTradeNo == "" {
+		return RefundRecord{}, errors.New("out_trade_no is missing in order")
+	}
+	remaining, err := m.remaining(ctx, req.Mchid, outTradeNo, order.Amount.OrderAmount)
+	if err != nil {
+		return RefundRecord{}, err
+	}
+	if req.RefundAmount > remaining {
+		return RefundRecord{}, fmt.Errorf("%w: refund_amount=%d refundable=%d", ErrOverRefund, req.RefundAmount, remaining)
+	}
+
+	now := m.opts.Now()
+	rec := RefundRecord{
+		Mchid:       req.Mchid,
+		OutTradeNo:  outTradeNo,
+		OutRefundNo: req.OutRefundNo,
+		Amount:      req.RefundAmount,
+		Status:      model.RefundInit,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	if err := m.ledger.Save(ctx, rec); err != nil {
+		return RefundRecord{}, err
+	}
+	return rec, nil
+}
+
+// remaining 计算订单金额减去台账中未失败退款后的剩余可退金额，台账按请求中的 mchid 与 out_trade_no 查询，与 reserve 保存时一致。
+func (m *RefundManager) remaining(ctx context.Context, mchid, outTradeNo string, orderAmount model.Money) (model.Money, error) {
+	records, err := m.ledger.List(ctx, mchid, outTradeNo)
+	if err != nil {
+		return 0, err
+	}
+	refunded := make([]model.Money, 0, len(records))
+	for _, rec := range records {
+		if rec.Status != model.RefundFail {
+			refunded = append(refunded, rec.Amount)
+		}
+	}
+	total, err := model.SumMoney(refunded...)
+	if err != nil {
+		return 0, err
+	}
+	remaining, err := orderAmount.Sub(total)
+	if err != nil {
+		return 0, err
+	}
+	if remaining < 0 {
+		remaining = 0
+	}
+	return remaining, nil
+}
+
+// MemoryRefundLedger 是 RefundLedger 的内存实现，适用于测试或单机场景。
+type MemoryRefundLedger struct {
+	mu      sync.Mutex
+	records map[string]RefundRecord
+}
+
+// NewMemoryRefundLedger 创建内存退款台账。
+func NewMemoryRefundLedger() *MemoryRefundLedger {
+	return &MemoryRefundLedger{records: make(map[string]RefundRecord)}
+}
+
+// Get 查询退款记录。
+func (l *MemoryRefundLedger) Get(ctx context.Context, mchid, outRefundNo string) (*RefundRecord, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	rec, ok := l.records[mchid+"/"+outRefundNo]
+	if !ok {
+		return nil, nil
+	}
+	return &rec, nil
+}
+
+// List 返回订单下的全部退款记录，按创建时间升序。
+func (l *MemoryRefundLedger) List(ctx context.Context, mchid, outTradeNo string) ([]RefundRecord, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	var out []RefundRecord
+	for _, rec := range l.records {
+		if rec.Mchid == mchid && rec.OutTradeNo == outTradeNo {
+			out = append(out, rec)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].CreatedAt.Before(out[j].CreatedAt) })
+	return out, nil
+}
+
+// Save 新增或更新退款记录。
+func (l *MemoryRefundLedger) Save(ctx context.Context, rec RefundRecord) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.records[rec.Mchid+"/"+rec.OutRefundNo] = rec
+	return nil
+}
+
+// Pending 返回创建时间早于 before 且未终态的退款记录，按创建时间升序。
+func (l *MemoryRefundLedger) Pending(ctx context.Context, before time.Time) ([]RefundRecord, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	var out []RefundRecord
+	for _, rec := range l.records {
+		if !rec.Status.IsTerminal() && rec.CreatedAt.Before(before) {
+			out = append(out, rec)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].CreatedAt.Before(out[j].CreatedAt) })
+	return out, nil
+}
@@ -0,0 +1,158 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/wneverfade/wechatpay-b2b/model"
+	"github.com/wneverfade/wechatpay-b2b/types"
+)
+
+const (
+	defaultRefundStaleAfter    = 10 * time.Minute
+	defaultRefundSweepInterval = time.Minute
+)
+
+// RefundEventType 表示退款巡检事件类型。
+type RefundEventType string
+
+const (
+	// RefundEventSucceeded 退款成功
+	RefundEventSucceeded RefundEventType = "SUCCEEDED"
+	// RefundEventFailed 退款失败，需要人工跟进
+	RefundEventFailed RefundEventType = "FAILED"
+	// RefundEventStuck 超过 StaleAfter 仍未终态
+	RefundEventStuck RefundEventType = "STUCK"
+	// RefundEventError 查询退款失败，或查询到的状态不能由台账状态流转得到（乱序结果，不更新台账）
+	RefundEventError RefundEventType = "ERROR"
+)
+
+// RefundEvent 表示一次退款巡检的结果。
+type RefundEvent struct {
+	Type        RefundEventType
+	Record      RefundRecord             // 更新后的台账记录
+	Refund      *types.GetRefundResponse // 查询结果，查询失败时为 nil
+	ChannelInfo types.RefundChannelInfo  // 退款渠道信息，供客服跟进
+	Err         error
+}
+
+// RefundSweeperOptions 退款巡检参数，零值使用默认配置。
+type RefundSweeperOptions struct {
+	StaleAfter time.Duration     // 创建超过该时长仍未终态的退款才会被巡检，默认 10 分钟
+	Interval   time.Duration     // Run 的巡检间隔，默认 1 分钟
+	OnEvent    func(RefundEvent) // 每条退款巡检后回调
+	OnError    func(error)       // Run 中读写台账失败时回调
+	Now        func() time.Time  // 当前时间，默认 time.Now
+}
+
+// RefundSweeper 定期通过 GetRefund 复查台账中长时间未终态的退款。
+type RefundSweeper struct {
+	orders  OrderService
+	ledger  RefundLedger
+	appKeys AppKeyResolver
+	opts    RefundSweeperOptions
+}
+
+// NewRefundSweeper 创建退款巡检器。
+func NewRefundSweeper(orders OrderService, ledger RefundLedger, appKeys AppKeyResolver, opts RefundSweeperOptions) *RefundSweeper {
+	if opts.StaleAfter <= 0 {
+		opts.StaleAfter = defaultRefundStaleAfter
+	}
+	if opts.Interval <= 0 {
+		opts.Interval = defaultRefundSweepInterval
+	}
+	if opts.Now == nil {
+		opts.Now = time.Now
+	}
+	return &RefundSweeper{orders: orders, ledger: ledger, appKeys: appKeys, opts: opts}
+}
+
+// Run 按 Interval 周期性巡检，直到 ctx 结束。
+func (w *RefundSweeper) Run(ctx context.Context) error {
+	ticker := time.NewTicker(w.opts.Interval)
+	defer ticker.Stop()
+	for {
+		if _, err := w.RunOnce(ctx); err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			if w.opts.OnError != nil {
+				w.opts.OnError(err)
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunOnce 巡检一次所有超时未终态的退款。
+func (w *RefundSweeper) RunOnce(ctx context.Context) ([]RefundEvent, error) {
+	if w.orders == nil || w.ledger == nil || w.appKeys == nil {
+		return nil, errors.New("refund sweeper is not initialized")
+	}
+	records, err := w.ledger.Pending(ctx, w.opts.Now().Add(-w.opts.StaleAfter))
+	if err != nil {
+		return nil, err
+	}
+
+	events := make([]RefundEvent, 0, len(records))
+	for _, rec := range records {
+		if err := ctx.Err(); err != nil {
+			return events, err
+		}
+		e := w.check(ctx, rec)
+		if e.Type != RefundEventError {
+			if err := w.ledger.Save(ctx, e.Record); err != nil {
+				return events, err
+			}
+		}
+		if w.opts.OnEvent != nil {
+			w.opts.OnEvent(e)
+		}
+		events = append(events, e)
+	}
+	return events, nil
+}
+
+// check 查询单笔退款并生成巡检事件。
+func (w *RefundSweeper) check(ctx context.Context, rec RefundRecord) RefundEvent {
+	e := RefundEvent{Type: RefundEventError, Record: rec}
+
+	appKey, err := w.appKeys.AppKey(ctx, rec.Mchid)
+	if err != nil {
+		e.Err = err
+		return e
+	}
+	resp, err := w.orders.GetRefund(ctx, types.GetRefundRequest{Mchid: rec.Mchid, OutRefundNo: rec.OutRefundNo}, appKey)
+	if err != nil {
+		e.Err = err
+		return e
+	}
+
+	e.Refund = resp
+	e.ChannelInfo = resp.RefundChannelInfo
+	if err := applyRefundStatus(&e.Record, resp.RefundStatus); err != nil {
+		// 查询结果比台账状态更旧，不更新台账。
+		e.Record = rec
+		e.Err = err
+		return e
+	}
+	if resp.RefundID != "" {
+		e.Record.RefundID = resp.RefundID
+	}
+	e.Record.UpdatedAt = w.opts.Now()
+
+	switch e.Record.Status {
+	case model.RefundSuccess:
+		e.Type = RefundEventSucceeded
+	case model.RefundFail:
+		e.Type = RefundEventFailed
+	default:
+		e.Type = RefundEventStuck
+	}
+	return e
+}
@@ -0,0 +1,153 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/wneverfade/wechatpay-b2b/model"
+	"github.com/wneverfade/wechatpay-b2b/types"
+)
+
+// SettlementRow 表示某商户某一天（Asia/Shanghai）的结算汇总。
+type SettlementRow struct {
+	Mchid       string      `json:"mchid"`
+	Date        string      `json:"date"`         // yyyy-MM-dd，订单按支付时间、退款按退款时间归属
+	Orders      int         `json:"orders"`       // 已支付订单数
+	Gross       model.Money `json:"gross"`        // 订单金额合计
+	PlatformFee model.Money `json:"platform_fee"` // 技术服务费合计
+	Refunds     model.Money `json:"refunds"`      // 退款成功金额合计
+	Net         model.Money `json:"net"`          // Gross - PlatformFee - Refunds
+	Settled     model.Money `json:"settled"`      // 已结算订单的 Gross - PlatformFee
+	Unsettled   model.Money `json:"unsettled"`    // 未结算订单的 Gross - PlatformFee
+}
+
+// SettlementReportBuilder 按商户与日期汇总订单和退款，用于与 GetBalance 返回的余额核对。
+type SettlementReportBuilder struct {
+	rows map[[2]string]*SettlementRow
+}
+
+// NewSettlementReportBuilder 创建结算报表构建器。
+func NewSettlementReportBuilder() *SettlementReportBuilder {
+	return &SettlementReportBuilder{rows: make(map[[2]string]*SettlementRow)}
+}
+
+// AddOrder 汇总一笔订单，未支付的订单会被忽略。
+func (b *SettlementReportBuilder) AddOrder(order *types.GetOrderResponse) error {
+	if order == nil {
+		return errors.New("order is nil")
+	}
+	if !order.PayStatus.IsPaid() {
+		return nil
+	}
+	if order.PayTime.IsZero() {
+		return fmt.Errorf("out_trade_no %s: pay_time is empty", order.OutTradeNo)
+	}
+	payTime := order.PayTime.Time
+
+	// 在副本上计算，全部成功后再写回，避免溢出时留下不完整或空的汇总行。
+	row := b.row(order.Mchid, payTime.In(model.ShanghaiLocation).Format("2006-01-02"))
+	income, err := order.Amount.OrderAmount.Sub(order.PlatformProfitFee)
+	if err != nil {
+		return err
+	}
+	if row.Gross, err = row.Gross.Add(order.Amount.OrderAmount); err != nil {
+		return err
+	}
+	if row.PlatformFee, err = row.PlatformFee.Add(order.PlatformProfitFee); err != nil {
+		return err
+	}
+	if row.Net, err = row.Net.Add(income); err != nil {
+		return err
+	}
+	if order.SettleStatus.IsSettled() {
+		row.Settled, err = row.Settled.Add(income)
+	} else {
+		row.Unsettled, err = row.Unsettled.Add(income)
+	}
+	if err != nil {
+		return err
+	}
+	row.Orders++
+	b.put(row)
+	return nil
+}
+
+// AddRefund 汇总一笔退款，未成功的退款会被忽略。GetRefundResponse 不含商户号，需要调用方传入。
+func (b *SettlementReportBuilder) AddRefund(mchid string, refund *types.GetRefundResponse) error {
+	if refund == nil {
+		return errors.New("refund is nil")
+	}
+	if refund.RefundStatus != model.RefundSuccess {
+		return nil
+	}
+	if refund.RefundTime.IsZero() {
+		return fmt.Errorf("out_refund_no %s: refund_time is empty", refund.OutRefundNo)
+	}
+	refundTime := refund.RefundTime.Time
+
+	row := b.row(mchid, refundTime.In(model.ShanghaiLocation).Format("2006-01-02"))
+	amount := refund.Amount.RefundAmount
+	var err error
+	if row.Refunds, err = row.Refunds.Add(amount); err != nil {
+		return err
+	}
+	if row.Net, err = row.Net.Sub(amount); err != nil {
+		return err
+	}
+	b.put(row)
+	return nil
+}
+
+// Rows 返回按商户号、日期排序的汇总行。
+func (b *SettlementReportBuilder) Rows() []SettlementRow {
+	out := make([]SettlementRow, 0, len(b.rows))
+	for _, row := range b.rows {
+		out = append(out, *row)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Mchid != out[j].Mchid {
+			return out[i].Mchid < out[j].Mchid
+		}
+		return out[i].Date < out[j].Date
+	})
+	return out
+}
+
+// Totals 返回商户跨日期的汇总，Date 为空。
+func (b *SettlementReportBuilder) Totals(mchid string) (SettlementRow, error) {
+	total := SettlementRow{Mchid: mchid}
+	for _, row := range b.rows {
+		if row.Mchid != mchid {
+			continue
+		}
+		var err error
+		total.Orders += row.Orders
+		for _, f := range []struct{ dst, src *model.Money }{
+			{&total.Gross, &row.Gross},
+			{&total.PlatformFee, &row.PlatformFee},
+			{&total.Refunds, &row.Refunds},
+			{&total.Net, &row.Net},
+			{&total.Settled, &row.Settled},
+			{&total.Unsettled, &row.Unsettled},
+		} {
+			if *f.dst, err = f.dst.Add(*f.src); err != nil {
+				return SettlementRow{}, err
+			}
+		}
+	}
+	return total, nil
+}
+
+// row 返回汇总行的副本，不存在时返回空行，修改后需通过 put 写回。
+func (b *SettlementReportBuilder) row(mchid, date string) SettlementRow {
+	if row, ok := b.rows[[2]string{mchid, date}]; ok {
+		return *row
+	}
+	return SettlementRow{Mchid: mchid, Date: date}
+}
+
+// put 写回汇总行。
+func (b *SettlementReportBuilder) put(row SettlementRow) {
+	b.rows[[2]string{row.Mchid, row.Date}] = &row
+}
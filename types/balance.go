@@ -16,9 +16,9 @@ type BalanceResponse struct {
 
 // WithdrawRequest 提现请求参数。
 type WithdrawRequest struct {
-	Mchid          string `json:"mchid"`
-	WithdrawAmount int64  `json:"withdraw_amount"`
-	OutWithdrawNo  string `json:"out_withdraw_no"`
+	Mchid          string      `json:"mchid"`
+	WithdrawAmount model.Money `json:"withdraw_amount"`
+	OutWithdrawNo  string      `json:"out_withdraw_no"`
 }
 
 // WithdrawResponse 提现返回参数。
@@ -36,7 +36,7 @@ type QueryWithdrawRequest struct {
 // QueryWithdrawResponse 查询提现状态返回参数。
 type QueryWithdrawResponse struct {
 	OutWithdrawNo  string               `json:"out_withdraw_no"`
-	WithdrawAmount int64                `json:"withdraw_amount"`
+	WithdrawAmount model.Money          `json:"withdraw_amount"`
 	Status         model.WithdrawStatus `json:"status"`
 	FailReason     string               `json:"fail_reason,omitempty"`
 	ErrCode        int                  `json:"errcode"`
@@ -1,21 +1,23 @@
 package types
 
+import "github.com/wneverfade/wechatpay-b2b/model"
+
 // RegisterMerchantRequest 商户号进件请求参数。
 type RegisterMerchantRequest struct {
-	IDDocTypeNum         uint32          `json:"id_doc_type_num"`
-	IDCardInfo           *IDCardInfo     `json:"id_card_info,omitempty"`
-	IDDocInfo            *IDDocInfo      `json:"id_doc_info,omitempty"`
-	AccountInfo          AccountInfo     `json:"account_info"`
-	ContactInfo          ContactInfo     `json:"contact_info"`
-	BusinessLicense      BusinessLicense `json:"business_license"`
-	MerchantShortname    string          `json:"merchant_shortname"`
-	OrganizationType     uint32          `json:"organization_type"`
-	Qualification        *Qualification  `json:"qualification,omitempty"`
-	BusinessAdditionDesc string          `json:"business_addition_desc,omitempty"`
-	BusinessAdditionPics string          `json:"business_addition_pics,omitempty"`
-	OpenType             uint32          `json:"open_type"`
-	ExtRegisterInfo      ExtRegisterInfo `json:"ext_register_info"`
-	ClientIP             string          `json:"client_ip"`
+	IDDocTypeNum         model.IDDocType        `json:"id_doc_type_num"`
+	IDCardInfo           *IDCardInfo            `json:"id_card_info,omitempty"`
+	IDDocInfo            *IDDocInfo             `json:"id_doc_info,omitempty"`
+	AccountInfo          AccountInfo            `json:"account_info"`
+	ContactInfo          ContactInfo            `json:"contact_info"`
+	BusinessLicense      BusinessLicense        `json:"business_license"`
+	MerchantShortname    string                 `json:"merchant_shortname"`
+	OrganizationType     model.OrganizationType `json:"organization_type"`
+	Qualification        *Qualification         `json:"qualification,omitempty"`
+	BusinessAdditionDesc string                 `json:"business_addition_desc,omitempty"`
+	BusinessAdditionPics string                 `json:"business_addition_pics,omitempty"`
+	OpenType             model.OpenType         `json:"open_type"`
+	ExtRegisterInfo      ExtRegisterInfo        `json:"ext_register_info"`
+	ClientIP             string                 `json:"client_ip"`
 }
 
 // IDCardInfo 经营者/法人身份证信息。
@@ -132,9 +134,9 @@ type MerchantOpenStatusInnerResp struct {
 
 // SubMerchantRegistrationStatus 申请状态信息。
 type SubMerchantRegistrationStatus struct {
-	ApplymentState     string                     `json:"applyment_state"`
+	ApplymentState     model.ApplymentState       `json:"applyment_state"`
 	ApplymentStateDesc string                     `json:"applyment_state_desc"`
-	SignState          string                     `json:"sign_state,omitempty"`
+	SignState          model.SignState            `json:"sign_state,omitempty"`
 	SignURL            string                     `json:"sign_url,omitempty"`
 	SubMchid           string                     `json:"sub_mchid,omitempty"`
 	AccountValidation  *MerchantAccountValidation `json:"account_validation,omitempty"`
@@ -144,15 +146,15 @@ type SubMerchantRegistrationStatus struct {
 
 // MerchantAccountValidation 汇款账户验证信息。
 type MerchantAccountValidation struct {
-	AccountName              string `json:"account_name"`
-	AccountNo                string `json:"account_no"`
-	PayAmount                int64  `json:"pay_amount"`
-	DestinationAccountNumber string `json:"destination_account_number"`
-	DestinationAccountName   string `json:"destination_account_name"`
-	DestinationAccountBank   string `json:"destination_account_bank"`
-	City                     string `json:"city"`
-	Remark                   string `json:"remark"`
-	Deadline                 string `json:"deadline"`
+	AccountName              string      `json:"account_name"`
+	AccountNo                string      `json:"account_no"`
+	PayAmount                model.Money `json:"pay_amount"`
+	DestinationAccountNumber string      `json:"destination_account_number"`
+	DestinationAccountName   string      `json:"destination_account_name"`
+	DestinationAccountBank   string      `json:"destination_account_bank"`
+	City                     string      `json:"city"`
+	Remark                   string      `json:"remark"`
+	Deadline                 model.Time  `json:"deadline"`
 }
 
 // MerchantAuditDetail 驳回原因详情。
@@ -29,16 +29,16 @@ type GetOrderResponse struct {
 	OutTradeNo            string             `json:"out_trade_no"`                      // 商户订单号
 	OrderID               string             `json:"order_id"`                          // B2b支付订单号
 	PayStatus             model.PayStatus    `json:"pay_status"`                        // 订单状态
-	PayTime               string             `json:"pay_time,omitempty"`                // 支付完成时间
+	PayTime               model.Time         `json:"pay_time,omitzero"`                 // 支付完成时间
 	Attach                string             `json:"attach,omitempty"`                  // 附加数据
 	PayerOpenID           string             `json:"payer_openid"`                      // 支付者
 	Amount                Amount             `json:"amount"`                            // 订单金额
 	WxPayTransactionID    string             `json:"wxpay_transaction_id,omitempty"`    // 微信支付订单号
 	Env                   int                `json:"env"`                               // 订单环境
-	SettleStatus          int                `json:"settle_status"`                     // 结算状态
-	SettleFinishTime      string             `json:"settle_finish_time,omitempty"`      // 结算完成时间
+	SettleStatus          model.SettleStatus `json:"settle_status"`                     // 结算状态
+	SettleFinishTime      model.Time         `json:"settle_finish_time,omitzero"`       // 结算完成时间
 	PlatformProfitPercent int                `json:"platform_profit_percent,omitempty"` // 技术服务费率
-	PlatformProfitFee     int                `json:"platform_profit_fee,omitempty"`     // 技术服务费
+	PlatformProfitFee     model.Money        `json:"platform_profit_fee,omitempty"`     // 技术服务费
 	BankType              string             `json:"bank_type,omitempty"`               // 银行类型
 	RefundStatus          model.RefundStatus `json:"refund_status,omitempty"`           // 退款状态
 	ErrCode               int                `json:"errcode,omitempty"`                 // 错误码
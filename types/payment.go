@@ -1,5 +1,7 @@
 package types
 
+import "github.com/wneverfade/wechatpay-b2b/model"
+
 // Order 表示单笔微信支付下单的参数。
 type Order struct {
 	Mchid        string       `json:"mchid"`
@@ -13,9 +15,9 @@ type Order struct {
 }
 
 // CombinedOrder 表示合单中的子单参数。
+// 子单的 appKey 不属于 signData，签名时通过 service.AppKeyResolver 按 mchid 获取。
 type CombinedOrder struct {
 	Mchid             string       `json:"mchid"`
-	AppKey            string       `json:"app_key"`
 	OutTradeNo        string       `json:"out_trade_no"`
 	Description       string       `json:"description"`
 	Amount            Amount       `json:"amount"`
@@ -27,27 +29,27 @@ type CombinedOrder struct {
 
 // ProductInfo 表示商品信息。
 type ProductInfo struct {
-	SPUID     string `json:"spu_id"`
-	SKUID     string `json:"sku_id"`
-	Title     string `json:"title"`
-	Path      string `json:"path"`
-	HeadImg   string `json:"head_img"`
-	Category  string `json:"category"`
-	SKUAttr   string `json:"sku_attr"`
-	OrgPrice  int32  `json:"org_price"`
-	SalePrice int32  `json:"sale_price"`
-	Quantity  int32  `json:"quantity"`
+	SPUID     string      `json:"spu_id"`
+	SKUID     string      `json:"sku_id"`
+	Title     string      `json:"title"`
+	Path      string      `json:"path"`
+	HeadImg   string      `json:"head_img"`
+	Category  string      `json:"category"`
+	SKUAttr   string      `json:"sku_attr"`
+	OrgPrice  model.Money `json:"org_price"`
+	SalePrice model.Money `json:"sale_price"`
+	Quantity  int32       `json:"quantity"`
 }
 
 // Amount 金额明细。
 type Amount struct {
-	ProductAmount int64  `json:"product_amount,omitempty"`
-	Freight       int64  `json:"freight,omitempty"`
-	Discount      int64  `json:"discount,omitempty"`
-	OtherFee      int64  `json:"other_fee,omitempty"`
-	OrderAmount   int64  `json:"order_amount"`
-	PayerAmount   int64  `json:"payer_amount,omitempty"`
-	Currency      string `json:"currency,omitempty"`
+	ProductAmount model.Money `json:"product_amount,omitempty"`
+	Freight       model.Money `json:"freight,omitempty"`
+	Discount      model.Money `json:"discount,omitempty"`
+	OtherFee      model.Money `json:"other_fee,omitempty"`
+	OrderAmount   model.Money `json:"order_amount"`
+	PayerAmount   model.Money `json:"payer_amount,omitempty"`
+	Currency      string      `json:"currency,omitempty"`
 }
 
 // CombinedPaymentSignData 合单支付的 signData 结构。
@@ -4,14 +4,14 @@ import "github.com/wneverfade/wechatpay-b2b/model"
 
 // RefundRequest 退款请求参数。
 type RefundRequest struct {
-	Mchid        string `json:"mchid"`
-	OutTradeNo   string `json:"out_trade_no,omitempty"`
-	OrderID      string `json:"order_id,omitempty"`
-	OutRefundNo  string `json:"out_refund_no"`
-	RefundAmount int64  `json:"refund_amount"`
-	RefundFrom   int32  `json:"refund_from"`
-	RefundReason int32  `json:"refund_reason,omitempty"`
-	Description  string `json:"description,omitempty"`
+	Mchid        string      `json:"mchid"`
+	OutTradeNo   string      `json:"out_trade_no,omitempty"`
+	OrderID      string      `json:"order_id,omitempty"`
+	OutRefundNo  string      `json:"out_refund_no"`
+	RefundAmount model.Money `json:"refund_amount"`
+	RefundFrom   int32       `json:"refund_from"`
+	RefundReason int32       `json:"refund_reason,omitempty"`
+	Description  string      `json:"description,omitempty"`
 }
 
 // RefundResponse 退款返回参数。
@@ -35,11 +35,11 @@ type GetRefundResponse struct {
 	OutRefundNo       string             `json:"out_refund_no"`
 	OrderID           string             `json:"order_id"`
 	OutTradeNo        string             `json:"out_trade_no"`
-	CreateTime        string             `json:"create_time"`
-	RefundTime        string             `json:"refund_time"`
+	CreateTime        model.Time         `json:"create_time"`
+	RefundTime        model.Time         `json:"refund_time"`
 	RefundDesc        string             `json:"refund_desc"`
 	WxpayRefundID     string             `json:"wxpay_refund_id"`
-	ReverseSettAmt    int                `json:"reverse_sett_amt"`
+	ReverseSettAmt    model.Money        `json:"reverse_sett_amt"`
 	Amount            RefundAmount       `json:"amount"`
 	Description       string             `json:"description"`
 	RefundStatus      model.RefundStatus `json:"refund_status"`
@@ -48,11 +48,13 @@ type GetRefundResponse struct {
 	ErrMsg            string             `json:"errmsg"`
 }
 
+// RefundAmount 退款金额信息。
 type RefundAmount struct {
-	OrderAmount  int `json:"order_amount"`
-	RefundAmount int `json:"refund_amount"`
+	OrderAmount  model.Money `json:"order_amount"`
+	RefundAmount model.Money `json:"refund_amount"`
 }
 
+// RefundChannelInfo 退款渠道信息。
 type RefundChannelInfo struct {
 	FundsAccount        string `json:"funds_account"`
 	UserReceivedAccount string `json:"user_received_account"`